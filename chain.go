@@ -0,0 +1,201 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zymatik-com/genobase/chainfile"
+	"github.com/zymatik-com/genobase/types"
+)
+
+// How often to log progress when importing chains.
+const chainImportLogInterval = 1000
+
+// ImportChains imports all the chains in a UCSC chain file (eg. hg19ToHg38.over.chain.gz)
+// for lifting over from one reference genome assembly to another. The file may be
+// gzip compressed. Chains on alternate contigs, unplaced scaffolds etc. are skipped.
+//
+// Chains from GRCh38 that were imported before the target assembly was recorded
+// are deleted when the database is migrated, and must be imported again.
+func (db *DB) ImportChains(ctx context.Context, from, to types.Reference, r io.Reader) error {
+	cr, err := chainfile.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("could not open chain file: %w", err)
+	}
+
+	tx, err := db.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	chainStmt, err := tx.PrepareNamedContext(ctx, `
+		INSERT INTO liftover_chain (
+			score, ref, ref_name, ref_size, ref_strand,
+			ref_start, ref_end, query_ref, query_name, query_size,
+			query_strand, query_start, query_end
+		) VALUES (
+			:score, :ref, :ref_name, :ref_size, :ref_strand,
+			:ref_start, :ref_end, :query_ref, :query_name, :query_size,
+			:query_strand, :query_start, :query_end
+		)`)
+	if err != nil {
+		return fmt.Errorf("could not prepare statement: %w", err)
+	}
+	defer chainStmt.Close()
+
+	alignmentStmt, err := tx.PrepareNamedContext(ctx, `INSERT INTO liftover_alignment (chain_id, ref_offset, query_offset, size)
+		VALUES (:chain_id, :ref_offset, :query_offset, :size)`)
+	if err != nil {
+		return fmt.Errorf("could not prepare statement: %w", err)
+	}
+	defer alignmentStmt.Close()
+
+	var imported, skipped int
+	for {
+		chain, alignments, err := cr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return fmt.Errorf("could not read chain: %w", err)
+		}
+
+		refName, refErr := types.ParseChromosome(string(chain.RefName), from)
+		queryName, queryErr := types.ParseChromosome(string(chain.QueryName), to)
+		if refErr != nil || queryErr != nil {
+			skipped++
+			continue
+		}
+
+		chain.Ref = from
+		chain.RefName = refName
+		chain.QueryRef = to
+		chain.QueryName = queryName
+
+		result, err := chainStmt.ExecContext(ctx, chain)
+		if err != nil {
+			return fmt.Errorf("could not store chain: %w", err)
+		}
+
+		chainID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("could not get chain id: %w", err)
+		}
+
+		for _, alignment := range alignments {
+			alignment.ChainID = chainID
+
+			if _, err := alignmentStmt.ExecContext(ctx, alignment); err != nil {
+				return fmt.Errorf("could not store alignment: %w", err)
+			}
+		}
+
+		imported++
+		if imported%chainImportLogInterval == 0 {
+			db.logger.Info("Importing chains", "imported", imported, "skipped", skipped)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	db.logger.Info("Imported chains", "imported", imported, "skipped", skipped)
+
+	return nil
+}
+
+// ExportChains writes all the chains for lifting over from one reference genome
+// assembly to another to a UCSC chain file. If any chromosomes are specified,
+// only chains on those chromosomes (in the source assembly) are exported.
+//
+// The export is lossy: chains are written with their database IDs (rather than
+// the IDs from the file they were imported from), fractional scores are
+// truncated to integers, and any chains skipped on import are missing.
+func (db *DB) ExportChains(ctx context.Context, from, to types.Reference, w io.Writer, chromosomes ...types.Chromosome) error {
+	query, args := "SELECT * FROM liftover_chain WHERE ref = ? AND query_ref = ? ORDER BY id", []any{from, to}
+	if len(chromosomes) > 0 {
+		var err error
+		query, args, err = sqlx.In("SELECT * FROM liftover_chain WHERE ref = ? AND query_ref = ? AND ref_name IN (?) ORDER BY id", from, to, chromosomes)
+		if err != nil {
+			return fmt.Errorf("could not build query: %w", err)
+		}
+	}
+
+	rows, err := db.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("could not query chains: %w", err)
+	}
+	defer rows.Close()
+
+	// Read all the chains up front, so that we aren't holding open a result
+	// set while querying alignments.
+	var chains []types.Chain
+	for rows.Next() {
+		var chain types.Chain
+		if err := rows.StructScan(&chain); err != nil {
+			return fmt.Errorf("could not unmarshal chain: %w", err)
+		}
+
+		chains = append(chains, chain)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not scan chains: %w", err)
+	}
+
+	cw := chainfile.NewWriter(w)
+
+	for i := range chains {
+		chain := &chains[i]
+
+		alignments, err := db.getAlignmentsBetween(ctx, chain.ID, 0, chain.RefEnd-chain.RefStart)
+		if err != nil {
+			return err
+		}
+
+		refName, err := chain.RefName.Format(types.ChromosomeStyleUCSC, from)
+		if err != nil {
+			return fmt.Errorf("could not format chromosome name: %w", err)
+		}
+
+		queryName, err := chain.QueryName.Format(types.ChromosomeStyleUCSC, to)
+		if err != nil {
+			return fmt.Errorf("could not format chromosome name: %w", err)
+		}
+
+		chain.RefName = types.Chromosome(refName)
+		chain.QueryName = types.Chromosome(queryName)
+
+		if err := cw.Write(chain, alignments); err != nil {
+			return fmt.Errorf("could not write chain: %w", err)
+		}
+	}
+
+	if err := cw.Flush(); err != nil {
+		return fmt.Errorf("could not flush chain file: %w", err)
+	}
+
+	db.logger.Info("Exported chains", "exported", len(chains))
+
+	return nil
+}
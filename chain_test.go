@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/chainfile"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestImportChains(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	f, err := os.Open("chainfile/testdata/example.chain")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+
+	require.NoError(t, db.ImportChains(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, f))
+
+	chain, err := db.GetChain(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr2, 1100)
+	require.NoError(t, err)
+
+	assert.Equal(t, types.ReferenceGRCh37, chain.Ref)
+	assert.Equal(t, types.ReferenceGRCh38, chain.QueryRef)
+	assert.Equal(t, types.Chr2, chain.RefName)
+	assert.Equal(t, types.Chr2, chain.QueryName)
+
+	lifted, err := db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr1, 227480)
+	require.NoError(t, err)
+
+	assert.Equal(t, types.Chr1, lifted.Chromosome)
+	assert.Equal(t, int64(257729), lifted.Position)
+
+	_, err = db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr2, 1121)
+	assert.ErrorIs(t, err, genobase.ErrDeleted)
+}
+
+func TestExportChains(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	f, err := os.Open("chainfile/testdata/export.chain")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+
+	require.NoError(t, db.ImportChains(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, f))
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, db.ExportChains(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, &buf))
+
+		exported, err := chainfile.NewReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+
+		_, err = f.Seek(0, 0)
+		require.NoError(t, err)
+
+		original, err := chainfile.NewReader(f)
+		require.NoError(t, err)
+
+		for _, id := range []int64{1, 2} {
+			chain, alignments, err := original.Next()
+			require.NoError(t, err)
+
+			exportedChain, exportedAlignments, err := exported.Next()
+			require.NoError(t, err)
+
+			// Chains are renumbered on import.
+			assert.NotEqual(t, chain.ID, exportedChain.ID)
+			assert.Equal(t, id, exportedChain.ID)
+
+			chain.ID = id
+			assert.Equal(t, chain, exportedChain)
+			assert.Equal(t, alignments, exportedAlignments)
+		}
+
+		_, _, err = exported.Next()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("Chromosomes", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, db.ExportChains(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, &buf, types.Chr2))
+
+		assert.Equal(t, "chain 500 chr2 243199373 + 1000 1250 chr2 242193529 + 2000 2200 2\n100\t50\t0\n100\n\n", buf.String())
+	})
+
+	t.Run("FractionalScore", func(t *testing.T) {
+		chain := "chain 1000.75 chr3 198022430 + 0 100 chr3 198295559 + 0 100 1\n100\n"
+		require.NoError(t, db.ImportChains(ctx, types.ReferenceGRCh37, types.ReferenceTelomereToTelomereV2, strings.NewReader(chain)))
+
+		var buf bytes.Buffer
+		require.NoError(t, db.ExportChains(ctx, types.ReferenceGRCh37, types.ReferenceTelomereToTelomereV2, &buf))
+
+		// Scores are stored as integers, so fractional scores are truncated.
+		assert.Equal(t, "chain 1000 chr3 198022430 + 0 100 chr3 198295559 + 0 100 3\n100\n\n", buf.String())
+	})
+}
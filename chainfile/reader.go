@@ -0,0 +1,174 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+// Package chainfile reads and writes UCSC chain files.
+// See: https://genome.ucsc.edu/goldenPath/help/chain.html
+package chainfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/zymatik-com/genobase/internal/decompress"
+	"github.com/zymatik-com/genobase/types"
+)
+
+// Reader is a streaming reader for UCSC chain files.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReader returns a new chain file reader. Gzip compressed input is
+// detected and decompressed automatically.
+func NewReader(r io.Reader) (*Reader, error) {
+	dr, err := decompress.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		scanner: bufio.NewScanner(dr),
+	}, nil
+}
+
+// Next returns the next chain in the file along with its alignment blocks.
+// Chromosome names are returned exactly as they appear in the file (eg. "chr1"),
+// and the chain ID is taken from the header (if present).
+// When there are no more chains, io.EOF is returned.
+func (r *Reader) Next() (*types.Chain, []types.Alignment, error) {
+	var chain *types.Chain
+	for r.scanner.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var err error
+		chain, err = parseHeader(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+
+		break
+	}
+
+	if chain == nil {
+		if err := r.scanner.Err(); err != nil {
+			return nil, nil, fmt.Errorf("could not read chain file: %w", err)
+		}
+
+		return nil, nil, io.EOF
+	}
+
+	var alignments []types.Alignment
+	var refOffset, queryOffset int64
+	for r.scanner.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			break
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 1 && len(fields) != 3 {
+			return nil, nil, fmt.Errorf("line %d: malformed alignment block", r.line)
+		}
+
+		values := make([]int64, len(fields))
+		for i, field := range fields {
+			var err error
+			values[i], err = strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: could not parse alignment block: %w", r.line, err)
+			}
+		}
+
+		alignments = append(alignments, types.Alignment{
+			RefOffset:   refOffset,
+			QueryOffset: queryOffset,
+			Size:        values[0],
+		})
+
+		// The last block in a chain has no trailing gap.
+		if len(values) == 1 {
+			break
+		}
+
+		// Gaps are relative to the end of the previous block.
+		refOffset += values[0] + values[1]
+		queryOffset += values[0] + values[2]
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("could not read chain file: %w", err)
+	}
+
+	if len(alignments) == 0 {
+		return nil, nil, fmt.Errorf("line %d: chain has no alignment blocks", r.line)
+	}
+
+	return chain, alignments, nil
+}
+
+// parseHeader parses a chain header line, eg.
+// "chain score tName tSize tStrand tStart tEnd qName qSize qStrand qStart qEnd id".
+func parseHeader(line string) (*types.Chain, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 12 || fields[0] != "chain" {
+		return nil, fmt.Errorf("malformed chain header")
+	}
+
+	// Some tools write the score as a floating point number.
+	score, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse chain score: %w", err)
+	}
+
+	var values [6]int64
+	for i, idx := range []int{3, 5, 6, 8, 10, 11} {
+		values[i], err = strconv.ParseInt(fields[idx], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse chain header: %w", err)
+		}
+	}
+
+	// The chain ID is optional.
+	var id int64
+	if len(fields) > 12 {
+		id, err = strconv.ParseInt(fields[12], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse chain id: %w", err)
+		}
+	}
+
+	return &types.Chain{
+		ID:          id,
+		Score:       int64(score),
+		RefName:     types.Chromosome(fields[2]),
+		RefSize:     values[0],
+		RefStrand:   fields[4],
+		RefStart:    values[1],
+		RefEnd:      values[2],
+		QueryName:   types.Chromosome(fields[7]),
+		QuerySize:   values[3],
+		QueryStrand: fields[9],
+		QueryStart:  values[4],
+		QueryEnd:    values[5],
+	}, nil
+}
@@ -0,0 +1,88 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package chainfile_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase/chainfile"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestReader(t *testing.T) {
+	data, err := os.ReadFile("testdata/example.chain")
+	require.NoError(t, err)
+
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err = gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	for name, input := range map[string][]byte{
+		"Plain":      data,
+		"Compressed": compressed.Bytes(),
+	} {
+		input := input
+
+		t.Run(name, func(t *testing.T) {
+			r, err := chainfile.NewReader(bytes.NewReader(input))
+			require.NoError(t, err)
+
+			chain, alignments, err := r.Next()
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(2), chain.ID)
+			assert.Equal(t, int64(20851231461), chain.Score)
+			assert.Equal(t, types.Chromosome("chr1"), chain.RefName)
+			assert.Equal(t, int64(249250621), chain.RefSize)
+			assert.Equal(t, "+", chain.RefStrand)
+			assert.Equal(t, int64(10000), chain.RefStart)
+			assert.Equal(t, int64(267719), chain.RefEnd)
+			assert.Equal(t, types.Chromosome("chr1"), chain.QueryName)
+			assert.Equal(t, int64(248956422), chain.QuerySize)
+			assert.Equal(t, "+", chain.QueryStrand)
+			assert.Equal(t, int64(10000), chain.QueryStart)
+			assert.Equal(t, int64(297968), chain.QueryEnd)
+
+			assert.Equal(t, []types.Alignment{
+				{RefOffset: 0, QueryOffset: 0, Size: 167417},
+				{RefOffset: 217417, QueryOffset: 247666, Size: 40302},
+			}, alignments)
+
+			chain, alignments, err = r.Next()
+			require.NoError(t, err)
+
+			assert.Equal(t, types.Chromosome("chr1_gl000191_random"), chain.RefName)
+			assert.Len(t, alignments, 1)
+
+			chain, alignments, err = r.Next()
+			require.NoError(t, err)
+
+			assert.Equal(t, types.Chromosome("chr2"), chain.RefName)
+			assert.Equal(t, []types.Alignment{
+				{RefOffset: 0, QueryOffset: 0, Size: 100},
+				{RefOffset: 150, QueryOffset: 100, Size: 100},
+			}, alignments)
+
+			_, _, err = r.Next()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
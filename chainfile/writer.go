@@ -0,0 +1,78 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package chainfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/zymatik-com/genobase/types"
+)
+
+// Writer writes chains in the UCSC chain file format.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter returns a new chain file writer. Flush must be called once all
+// chains have been written.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Write writes a chain and its alignment blocks (which must be sorted by
+// their reference offset). Chromosome names are written as is, and the
+// chain ID is used as the chain's identifier.
+func (w *Writer) Write(chain *types.Chain, alignments []types.Alignment) error {
+	if len(alignments) == 0 {
+		return fmt.Errorf("chain %d has no alignment blocks", chain.ID)
+	}
+
+	if _, err := fmt.Fprintf(w.w, "chain %d %s %d %s %d %d %s %d %s %d %d %d\n",
+		chain.Score, chain.RefName, chain.RefSize, chain.RefStrand, chain.RefStart, chain.RefEnd,
+		chain.QueryName, chain.QuerySize, chain.QueryStrand, chain.QueryStart, chain.QueryEnd, chain.ID); err != nil {
+		return fmt.Errorf("could not write chain header: %w", err)
+	}
+
+	for i, alignment := range alignments {
+		// The last block in a chain has no trailing gap.
+		if i == len(alignments)-1 {
+			if _, err := fmt.Fprintf(w.w, "%d\n\n", alignment.Size); err != nil {
+				return fmt.Errorf("could not write alignment block: %w", err)
+			}
+
+			break
+		}
+
+		next := alignments[i+1]
+		refGap := next.RefOffset - (alignment.RefOffset + alignment.Size)
+		queryGap := next.QueryOffset - (alignment.QueryOffset + alignment.Size)
+		if refGap < 0 || queryGap < 0 {
+			return fmt.Errorf("chain %d has overlapping alignment blocks", chain.ID)
+		}
+
+		if _, err := fmt.Fprintf(w.w, "%d\t%d\t%d\n", alignment.Size, refGap, queryGap); err != nil {
+			return fmt.Errorf("could not write alignment block: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
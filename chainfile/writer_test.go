@@ -0,0 +1,60 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package chainfile_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase/chainfile"
+)
+
+func TestWriter(t *testing.T) {
+	f, err := os.Open("testdata/export.chain")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+
+	r, err := chainfile.NewReader(f)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w := chainfile.NewWriter(&buf)
+
+	var nChains int
+	for {
+		chain, alignments, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+
+		require.NoError(t, w.Write(chain, alignments))
+		nChains++
+	}
+
+	require.NoError(t, w.Flush())
+	assert.Equal(t, 2, nChains)
+
+	// The example file is in the same format as the writer produces.
+	expected, err := os.ReadFile("testdata/export.chain")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), buf.String())
+}
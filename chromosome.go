@@ -0,0 +1,112 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zymatik-com/genobase/types"
+)
+
+// GetChromosomeLength returns the length of a chromosome in base pairs on a
+// reference genome assembly.
+func (db *DB) GetChromosomeLength(ctx context.Context, reference types.Reference, chromosome types.Chromosome) (int64, error) {
+	var length int64
+	if err := db.db.GetContext(ctx, &length, "SELECT length FROM chromosome_length WHERE ref = ? AND chromosome = ?",
+		reference, chromosome); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("unknown length of chromosome %s on %s: %w", chromosome, reference, os.ErrNotExist)
+		}
+
+		return 0, fmt.Errorf("could not query chromosome length: %w", err)
+	}
+
+	return length, nil
+}
+
+// ImportFastaIndex imports the chromosome lengths of a reference genome
+// assembly from a FASTA index (eg. GRCh38_full_analysis_set.fna.fai). New
+// assemblies are added to the database. Sequences on alternate contigs,
+// unplaced scaffolds etc. are skipped.
+func (db *DB) ImportFastaIndex(ctx context.Context, reference types.Reference, r io.Reader) error {
+	tx, err := db.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO reference (id) VALUES (?)", reference); err != nil {
+		return fmt.Errorf("could not store reference: %w", err)
+	}
+
+	var imported, skipped int
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		// Columns: NAME, LENGTH, OFFSET, LINEBASES, LINEWIDTH.
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 2 {
+			return fmt.Errorf("line %d: malformed fasta index", line)
+		}
+
+		chromosome, err := types.ParseChromosome(fields[0], reference)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		length, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: could not parse length: %w", line, err)
+		}
+
+		if err := storeChromosomeLength(ctx, tx, reference, chromosome, length); err != nil {
+			return err
+		}
+
+		imported++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read fasta index: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	db.logger.Info("Imported chromosome lengths", "reference", reference, "imported", imported, "skipped", skipped)
+
+	return nil
+}
+
+// storeChromosomeLength stores (or updates) the length of a chromosome on a
+// reference genome assembly, within an import transaction.
+func storeChromosomeLength(ctx context.Context, tx *sqlx.Tx, reference types.Reference, chromosome types.Chromosome, length int64) error {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO chromosome_length (ref, chromosome, length) VALUES (?, ?, ?)
+		ON CONFLICT(ref, chromosome) DO UPDATE SET length = excluded.length`, reference, chromosome, length); err != nil {
+		return fmt.Errorf("could not store chromosome length: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestChromosomeLength(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	chromosomes := []types.Chromosome{
+		types.Chr1, types.Chr2, types.Chr3, types.Chr4, types.Chr5, types.Chr6, types.Chr7, types.Chr8,
+		types.Chr9, types.Chr10, types.Chr11, types.Chr12, types.Chr13, types.Chr14, types.Chr15, types.Chr16,
+		types.Chr17, types.Chr18, types.Chr19, types.Chr20, types.Chr21, types.Chr22, types.ChrX, types.ChrY,
+		types.ChrMT, types.ChrPAR, types.ChrPAR2,
+	}
+
+	t.Run("Builtin", func(t *testing.T) {
+		for _, reference := range []types.Reference{
+			types.ReferenceNCBI36, types.ReferenceGRCh37, types.ReferenceGRCh38, types.ReferenceTelomereToTelomereV2,
+		} {
+			for _, chromosome := range chromosomes {
+				length := chromosome.Length(reference)
+
+				stored, err := db.GetChromosomeLength(ctx, reference, chromosome)
+				require.NoError(t, err)
+
+				assert.Equal(t, length, stored, "%s chromosome %s", reference, chromosome)
+			}
+		}
+
+		assert.Equal(t, int64(249250621), types.Chr1.Length(types.ReferenceGRCh37))
+
+		assert.Panics(t, func() { types.Chr1.Length("hg16") })
+		assert.Panics(t, func() { types.Chromosome("chr1").Length(types.ReferenceGRCh38) })
+	})
+
+	t.Run("FastaIndex", func(t *testing.T) {
+		fai := "chr1\t248956422\t112\t70\t71\n" +
+			"chr1_KI270706v1_random\t175055\t252513167\t70\t71\n" +
+			"chrM\t16569\t3105575214\t70\t71\n"
+
+		require.NoError(t, db.ImportFastaIndex(ctx, "hs38DH", strings.NewReader(fai)))
+
+		length, err := db.GetChromosomeLength(ctx, "hs38DH", types.Chr1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(248956422), length)
+
+		length, err = db.GetChromosomeLength(ctx, "hs38DH", types.ChrMT)
+		require.NoError(t, err)
+		assert.Equal(t, int64(16569), length)
+
+		_, err = db.GetChromosomeLength(ctx, "hs38DH", types.Chr2)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestParseChromosome(t *testing.T) {
+	for name, expected := range map[string]types.Chromosome{
+		"chr1":         types.Chr1,
+		"1":            types.Chr1,
+		"Chr22":        types.Chr22,
+		"NC_000001.11": types.Chr1,
+		"NC_000023.11": types.ChrX,
+		"NC_000024":    types.ChrY,
+		"CM000663.2":   types.Chr1,
+		"CM000686.2":   types.ChrY,
+		"chrM":         types.ChrMT,
+		"MT":           types.ChrMT,
+		"NC_012920.1":  types.ChrMT,
+		"23":           types.ChrX,
+		"24":           types.ChrY,
+		"25":           types.ChrX,
+		"26":           types.ChrMT,
+		"XY":           types.ChrX,
+		"chrX":         types.ChrX,
+		"PAR":          types.ChrPAR,
+		"PAR1":         types.ChrPAR,
+		"PAR2":         types.ChrPAR2,
+	} {
+		chromosome, err := types.ParseChromosome(name, types.ReferenceGRCh38)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, chromosome, name)
+	}
+
+	chromosome, err := types.ParseChromosome("NC_060925.1", types.ReferenceTelomereToTelomereV2)
+	require.NoError(t, err)
+	assert.Equal(t, types.Chr1, chromosome)
+
+	chromosome, err = types.ParseChromosome("CP068256.2", types.ReferenceTelomereToTelomereV2)
+	require.NoError(t, err)
+	assert.Equal(t, types.Chr22, chromosome)
+
+	// Accessions from other assemblies.
+	_, err = types.ParseChromosome("NC_060925.1", types.ReferenceGRCh38)
+	assert.Error(t, err)
+
+	_, err = types.ParseChromosome("CM000663.2", types.ReferenceTelomereToTelomereV2)
+	assert.Error(t, err)
+
+	// Unknown assemblies accept any accession.
+	chromosome, err = types.ParseChromosome("CM000663.2", "hs38DH")
+	require.NoError(t, err)
+	assert.Equal(t, types.Chr1, chromosome)
+
+	for _, name := range []string{"", "chr", "0", "01", "27", "chr1_KI270706v1_random", "chrUn_KI270302v1", "NC_000025.1"} {
+		_, err := types.ParseChromosome(name, types.ReferenceGRCh38)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestFormatChromosome(t *testing.T) {
+	for _, tc := range []struct {
+		chromosome types.Chromosome
+		style      types.ChromosomeStyle
+		reference  types.Reference
+		expected   string
+	}{
+		{types.Chr1, types.ChromosomeStyleUCSC, types.ReferenceGRCh38, "chr1"},
+		{types.ChrMT, types.ChromosomeStyleUCSC, types.ReferenceGRCh38, "chrM"},
+		{types.ChrX, types.ChromosomeStyleEnsembl, types.ReferenceGRCh38, "X"},
+		{types.ChrMT, types.ChromosomeStyleEnsembl, types.ReferenceGRCh38, "MT"},
+		{types.Chr1, types.ChromosomeStyleRefSeq, types.ReferenceGRCh38, "NC_000001.11"},
+		{types.Chr1, types.ChromosomeStyleRefSeq, types.ReferenceGRCh37, "NC_000001.10"},
+		{types.ChrX, types.ChromosomeStyleRefSeq, types.ReferenceGRCh38, "NC_000023.11"},
+		{types.ChrMT, types.ChromosomeStyleRefSeq, types.ReferenceGRCh37, "NC_012920.1"},
+		{types.ChrY, types.ChromosomeStyleRefSeq, types.ReferenceTelomereToTelomereV2, "NC_060948.1"},
+		{types.Chr22, types.ChromosomeStylePLINK, types.ReferenceGRCh38, "22"},
+		{types.ChrX, types.ChromosomeStylePLINK, types.ReferenceGRCh38, "23"},
+		{types.ChrY, types.ChromosomeStylePLINK, types.ReferenceGRCh38, "24"},
+		{types.ChrMT, types.ChromosomeStylePLINK, types.ReferenceGRCh38, "26"},
+	} {
+		name, err := tc.chromosome.Format(tc.style, tc.reference)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, name)
+
+		// Formatted names should round trip.
+		chromosome, err := types.ParseChromosome(name, tc.reference)
+		require.NoError(t, err)
+		assert.Equal(t, tc.chromosome, chromosome)
+	}
+
+	// The pseudoautosomal regions have no name.
+	for _, style := range []types.ChromosomeStyle{
+		types.ChromosomeStyleUCSC, types.ChromosomeStyleEnsembl, types.ChromosomeStyleRefSeq, types.ChromosomeStylePLINK,
+	} {
+		_, err := types.ChrPAR.Format(style, types.ReferenceGRCh38)
+		assert.Error(t, err, style)
+
+		_, err = types.ChrPAR2.Format(style, types.ReferenceGRCh38)
+		assert.Error(t, err, style)
+	}
+
+	_, err := types.Chr1.Format(types.ChromosomeStyleRefSeq, types.ReferenceNCBI36)
+	assert.Error(t, err)
+
+	_, err = types.Chromosome("chr1").Format(types.ChromosomeStyleEnsembl, types.ReferenceGRCh38)
+	assert.Error(t, err)
+}
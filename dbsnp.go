@@ -0,0 +1,228 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/zymatik-com/genobase/types"
+	"github.com/zymatik-com/genobase/vcf"
+)
+
+// ImportDbSNP imports the variants from a NCBI dbSNP VCF file
+// (eg. GCF_000001405.40.gz). The file may be gzip compressed. Variants on
+// alternate contigs, unplaced scaffolds etc. are skipped. If a sequence source
+// has been set, variants are normalized before they are stored.
+//
+// As alleles are looked up one at a time, each alternate allele is normalized
+// separately (as with bcftools norm -m-). A variant only has a single position,
+// so if the alternate alleles of a record normalize to different positions, the
+// position of the first allele matching the record's variant class is stored.
+//
+// Files for assemblies other than GRCh38 (eg. GCF_000001405.25.gz for GRCh37)
+// can be imported with the Assembly option, in which case only the positions
+// of the variants on that assembly are stored. These are in addition to the
+// GRCh38 release, which must be imported first: variants that are not in the
+// GRCh38 release are skipped.
+//
+// Positions on other assemblies are normalized against the imported sequence
+// of that assembly (see ImportSequence), so it should be imported first.
+// Otherwise positions are stored as is, and as indels may not be left-aligned,
+// LiftoverVariant may lift them from the wrong position.
+func (db *DB) ImportDbSNP(ctx context.Context, r io.Reader, opts ...ImportOption) error {
+	options := newImportOptions(opts...)
+
+	vr, err := vcf.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("could not open vcf file: %w", err)
+	}
+
+	var imported, skipped int
+	batch := make([]types.Variant, 0, options.batchSize)
+
+	flush := func() error {
+		stored := len(batch)
+		if options.assembly == types.ReferenceGRCh38 {
+			if err := db.StoreVariants(ctx, batch); err != nil {
+				return err
+			}
+		} else {
+			ids := make([]int64, 0, len(batch))
+			for _, variant := range batch {
+				ids = append(ids, variant.ID)
+			}
+
+			variants, err := db.GetVariantsByIDs(ctx, ids)
+			if err != nil {
+				return err
+			}
+
+			positions := make([]types.VariantPosition, 0, len(batch))
+			for _, variant := range batch {
+				// Positions are only stored for variants in the GRCh38 release.
+				if _, ok := variants[variant.ID]; !ok {
+					skipped++
+					continue
+				}
+
+				positions = append(positions, types.VariantPosition{
+					ID:         variant.ID,
+					Reference:  options.assembly,
+					Chromosome: variant.Chromosome,
+					Position:   variant.Position,
+				})
+			}
+
+			if err := db.StoreVariantPositions(ctx, positions); err != nil {
+				return err
+			}
+
+			stored = len(positions)
+		}
+
+		imported += stored
+		batch = batch[:0]
+
+		db.logger.Info("Importing variants", "imported", imported, "skipped", skipped)
+
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := vr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return fmt.Errorf("could not read variant: %w", err)
+		}
+
+		chromosome, err := types.ParseChromosome(record.Chromosome, options.assembly)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		vc, _ := record.Info("VC")
+		class, ok := parseVariantClass(vc)
+		if !ok {
+			skipped++
+			continue
+		}
+
+		position, err := db.normalizedPosition(ctx, options.assembly, chromosome, record, class)
+		if err != nil {
+			return fmt.Errorf("could not normalize variant at %s:%d: %w", record.Chromosome, record.Position, err)
+		}
+
+		for _, id := range record.IDs {
+			rsid, ok := parseRSID(id)
+			if !ok {
+				skipped++
+				continue
+			}
+
+			batch = append(batch, types.Variant{
+				ID:         rsid,
+				Chromosome: chromosome,
+				Position:   position,
+				Class:      class,
+			})
+		}
+
+		if len(batch) >= options.batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	db.logger.Info("Imported variants", "imported", imported, "skipped", skipped)
+
+	return nil
+}
+
+// normalizedPosition returns the normalized position of a dbSNP record: that of
+// the first alternate allele (normalized on its own) of the given class, or of
+// the first alternate allele if none match.
+func (db *DB) normalizedPosition(ctx context.Context, reference types.Reference, chromosome types.Chromosome,
+	record *vcf.Record, class types.VariantClass) (int64, error) {
+	position := record.Position
+	for i, alternate := range record.Alternate {
+		normalizedPosition, ref, alts, err := db.normalizeVariant(ctx, reference, chromosome, record.Position, record.Reference, alternate)
+		if err != nil {
+			return 0, err
+		}
+
+		alleleClass := types.ClassifyVariant(ref, alts[0])
+		if alleleClass == class || (class == types.VariantClassINDEL && len(ref) != len(alts[0])) {
+			return normalizedPosition, nil
+		}
+
+		if i == 0 {
+			position = normalizedPosition
+		}
+	}
+
+	return position, nil
+}
+
+// parseVariantClass converts a dbSNP variant class (the VC INFO field) into a
+// variant class.
+func parseVariantClass(vc string) (types.VariantClass, bool) {
+	switch strings.ToUpper(vc) {
+	case "SNV":
+		return types.VariantClassSNV, true
+	case "MNV":
+		return types.VariantClassMNV, true
+	case "INS":
+		return types.VariantClassINS, true
+	case "DEL":
+		return types.VariantClassDEL, true
+	// Older dbSNP builds use DIV for deletion/insertion variants.
+	case "INDEL", "DIV":
+		return types.VariantClassINDEL, true
+	}
+
+	return "", false
+}
+
+// parseRSID parses a reference SNP ID (eg. "rs334").
+func parseRSID(id string) (int64, bool) {
+	if len(id) <= 2 || !strings.EqualFold(id[:2], "rs") {
+		return 0, false
+	}
+
+	rsid, err := strconv.ParseInt(id[2:], 10, 64)
+	if err != nil || rsid <= 0 {
+		return 0, false
+	}
+
+	return rsid, true
+}
@@ -0,0 +1,173 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestImportDbSNP(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	f, err := os.Open("vcf/testdata/example.vcf")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+
+	require.NoError(t, db.ImportDbSNP(ctx, f, genobase.BatchSize(2)))
+
+	variant, err := db.GetVariant(ctx, 334)
+	require.NoError(t, err)
+
+	assert.Equal(t, types.Chr11, variant.Chromosome)
+	assert.Equal(t, int64(5227002), variant.Position)
+	assert.Equal(t, types.VariantClassSNV, variant.Class)
+
+	variant, err = db.GetVariant(ctx, 113993960)
+	require.NoError(t, err)
+
+	assert.Equal(t, types.Chr7, variant.Chromosome)
+	assert.Equal(t, types.VariantClassDEL, variant.Class)
+
+	// On an unplaced scaffold.
+	_, err = db.GetVariant(ctx, 1570391677)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	t.Run("Assembly", func(t *testing.T) {
+		grch37 := "#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n" +
+			"NC_000011.9\t5248232\trs334\tT\tA,C,G\t.\t.\tRS=334;VC=SNV\n" +
+			"NC_000022.10\t19951271\trs4680\tG\tA\t.\t.\tRS=4680;VC=SNV\n"
+
+		require.NoError(t, db.ImportDbSNP(ctx, strings.NewReader(grch37), genobase.Assembly(types.ReferenceGRCh37)))
+
+		variant, err := db.GetVariant(ctx, 334)
+		require.NoError(t, err)
+
+		// The GRCh38 position is unchanged.
+		assert.Equal(t, int64(5227002), variant.Position)
+		assert.Equal(t, map[types.Reference]types.Locus{
+			types.ReferenceGRCh37: {Chromosome: types.Chr11, Position: 5248232},
+			types.ReferenceGRCh38: {Chromosome: types.Chr11, Position: 5227002},
+		}, variant.Positions)
+
+		require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+			{ID: 334, Reference: "T", Alternate: "A", Ancestry: types.AncestryGroupAll, Frequency: 0.003480},
+		}))
+
+		// No chains are needed.
+		allele, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh37, types.Chr11, 5248232, "T", "A", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, int64(334), allele.ID)
+
+		positions, err := db.GetVariantPositions(ctx, 4680)
+		require.NoError(t, err)
+		assert.Len(t, positions, 2)
+		assert.Equal(t, types.Locus{Chromosome: types.Chr22, Position: 19951271}, positions[types.ReferenceGRCh37])
+	})
+
+	t.Run("AssemblyOnly", func(t *testing.T) {
+		grch37 := "#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n" +
+			"NC_000001.10\t10177\trs367896724\tA\tAC\t.\t.\tRS=367896724;VC=INDEL\n"
+
+		require.NoError(t, db.ImportDbSNP(ctx, strings.NewReader(grch37), genobase.Assembly(types.ReferenceGRCh37)))
+
+		// The variant isn't in the GRCh38 release, so is skipped.
+		_, err := db.GetVariant(ctx, 367896724)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		positions, err := db.GetVariantPositions(ctx, 367896724)
+		require.NoError(t, err)
+		assert.Empty(t, positions)
+
+		// Positions can only be stored for stored variants.
+		err = db.StoreVariantPositions(ctx, []types.VariantPosition{
+			{ID: 367896724, Reference: types.ReferenceGRCh37, Chromosome: types.Chr1, Position: 10177},
+		})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Normalized", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "genobase.db")
+
+		db, err := genobase.Open(ctx, slogt.New(t), path)
+		require.NoError(t, err)
+
+		// A (CA)n repeat, starting at position 6.
+		require.NoError(t, db.ImportSequence(ctx, types.ReferenceGRCh38, strings.NewReader(">chr1\nGGGGTCACACAGGGGG\n")))
+		require.NoError(t, db.Close())
+
+		// The imported sequence is used for normalization, without having to set it.
+		db, err = genobase.Open(ctx, slogt.New(t), path)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, db.Close())
+		})
+
+		// A deletion and an SNV in the same record, and an indel on a chromosome without sequence.
+		vcf := "#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n" +
+			"NC_000001.11\t9\trs1\tACA\tA,GCA\t.\t.\tRS=1;VC=INDEL\n" +
+			"NC_000002.12\t100\trs2\tCA\tC\t.\t.\tRS=2;VC=DEL\n"
+
+		require.NoError(t, db.ImportDbSNP(ctx, strings.NewReader(vcf)))
+
+		// Normalized on its own, rather than together with the SNV (which would be position 7).
+		variant, err := db.GetVariant(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), variant.Position)
+
+		variant, err = db.GetVariant(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), variant.Position)
+
+		require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+			{ID: 1, Reference: "TCA", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+		}))
+
+		allele, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr1, 9, "ACA", "A", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), allele.ID)
+
+		// Positions on other assemblies are normalized against their own sequence.
+		require.NoError(t, db.ImportSequence(ctx, types.ReferenceGRCh37, strings.NewReader(">chr1\nGGGTCACACAGGGGGG\n")))
+
+		grch37 := "#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n" +
+			"NC_000001.10\t8\trs1\tACA\tA\t.\t.\tRS=1;VC=DEL\n"
+
+		require.NoError(t, db.ImportDbSNP(ctx, strings.NewReader(grch37), genobase.Assembly(types.ReferenceGRCh37)))
+
+		positions, err := db.GetVariantPositions(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, types.Locus{Chromosome: types.Chr1, Position: 4}, positions[types.ReferenceGRCh37])
+
+		allele, err = db.GetAlleleByPosition(ctx, types.ReferenceGRCh37, types.Chr1, 8, "ACA", "A", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), allele.ID)
+	})
+}
@@ -0,0 +1,167 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+// Package fasta reads (possibly very large) sequences from FASTA files.
+// See: https://www.ncbi.nlm.nih.gov/genbank/fastaformat/
+package fasta
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zymatik-com/genobase/internal/decompress"
+)
+
+// Chunk is a contiguous piece of a sequence.
+type Chunk struct {
+	// Name is the name of the sequence (the first word of its header line).
+	Name string
+	// Offset is the 0-based offset of the first base of the chunk in the sequence.
+	Offset int64
+	// Bases are the bases of the chunk, exactly as they appear in the file.
+	Bases []byte
+}
+
+// Reader is a streaming reader for FASTA files. Sequences are returned in
+// fixed size chunks, so that whole chromosomes need not be held in memory.
+type Reader struct {
+	br        *bufio.Reader
+	chunkSize int
+	name      string
+	offset    int64
+	buf       []byte
+	// The name of the next sequence, once the current one has been returned.
+	next      *string
+	lineStart bool
+	eof       bool
+}
+
+// NewReader returns a new FASTA reader that returns sequences in chunks of
+// chunkSize bases (the last chunk of each sequence may be shorter). Gzip
+// compressed input is detected and decompressed automatically.
+func NewReader(r io.Reader, chunkSize int) (*Reader, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	dr, err := decompress.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		br:        bufio.NewReader(dr),
+		chunkSize: chunkSize,
+		lineStart: true,
+	}, nil
+}
+
+// Next returns the next chunk of sequence in the file. When there are no more
+// chunks, io.EOF is returned.
+func (r *Reader) Next() (*Chunk, error) {
+	for {
+		if len(r.buf) == 0 && r.next != nil {
+			r.name, r.offset, r.next = *r.next, 0, nil
+		}
+
+		if len(r.buf) >= r.chunkSize {
+			return r.emit(r.chunkSize), nil
+		}
+
+		if r.eof {
+			if len(r.buf) > 0 {
+				return r.emit(len(r.buf)), nil
+			}
+
+			return nil, io.EOF
+		}
+
+		// Lines can be very long (some files have a single line per sequence),
+		// so they are read in pieces.
+		piece, err := r.br.ReadSlice('\n')
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("could not read fasta file: %w", err)
+		}
+		r.eof = errors.Is(err, io.EOF)
+
+		if len(piece) == 0 {
+			continue
+		}
+
+		if r.lineStart && (piece[0] == '>' || piece[0] == ';') {
+			line := string(piece)
+			if errors.Is(err, bufio.ErrBufferFull) {
+				rest, err := r.br.ReadString('\n')
+				if err != nil && !errors.Is(err, io.EOF) {
+					return nil, fmt.Errorf("could not read fasta file: %w", err)
+				}
+				r.eof = errors.Is(err, io.EOF)
+
+				line += rest
+			}
+
+			// Comments.
+			if line[0] == ';' {
+				continue
+			}
+
+			fields := strings.Fields(line[1:])
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("sequence has no name")
+			}
+
+			name := fields[0]
+			r.next = &name
+
+			// Return the remainder of the current sequence first.
+			if len(r.buf) > 0 {
+				return r.emit(len(r.buf)), nil
+			}
+
+			continue
+		}
+
+		r.lineStart = piece[len(piece)-1] == '\n'
+
+		if r.name == "" && r.next == nil {
+			if len(bytes.TrimSpace(piece)) == 0 {
+				continue
+			}
+
+			return nil, fmt.Errorf("sequence data before header")
+		}
+
+		for _, base := range piece {
+			if base != '\n' && base != '\r' && base != ' ' && base != '\t' {
+				r.buf = append(r.buf, base)
+			}
+		}
+	}
+}
+
+// emit returns the first n buffered bases as a chunk.
+func (r *Reader) emit(n int) *Chunk {
+	chunk := &Chunk{
+		Name:   r.name,
+		Offset: r.offset,
+		Bases:  append([]byte(nil), r.buf[:n]...),
+	}
+
+	r.buf = r.buf[:copy(r.buf, r.buf[n:])]
+	r.offset += int64(n)
+
+	return chunk
+}
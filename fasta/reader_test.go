@@ -0,0 +1,89 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package fasta_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase/fasta"
+)
+
+func TestReader(t *testing.T) {
+	data, err := os.ReadFile("testdata/example.fa")
+	require.NoError(t, err)
+
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err = gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	for name, input := range map[string][]byte{
+		"Plain":      data,
+		"Compressed": compressed.Bytes(),
+	} {
+		input := input
+
+		t.Run(name, func(t *testing.T) {
+			r, err := fasta.NewReader(bytes.NewReader(input), 8)
+			require.NoError(t, err)
+
+			var chunks []fasta.Chunk
+			for {
+				chunk, err := r.Next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+
+				chunks = append(chunks, *chunk)
+			}
+
+			assert.Equal(t, []fasta.Chunk{
+				{Name: "chr1", Offset: 0, Bases: []byte("NNNNACGT")},
+				{Name: "chr1", Offset: 8, Bases: []byte("ACGTacgt")},
+				{Name: "chr1", Offset: 16, Bases: []byte("RYKMAC")},
+				{Name: "chrUn_KI270302v1", Offset: 0, Bases: []byte("ACGT")},
+				{Name: "NC_000002.12", Offset: 0, Bases: []byte("TTTTGGGG")},
+				{Name: "NC_000002.12", Offset: 8, Bases: []byte("CCCCAAAA")},
+			}, chunks)
+		})
+	}
+
+	t.Run("LongLines", func(t *testing.T) {
+		sequence := bytes.Repeat([]byte("ACGT"), 100_000)
+
+		r, err := fasta.NewReader(bytes.NewReader(append([]byte(">chr1\n"), sequence...)), 65536)
+		require.NoError(t, err)
+
+		var read []byte
+		for {
+			chunk, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(len(read)), chunk.Offset)
+			read = append(read, chunk.Bases...)
+		}
+
+		assert.Equal(t, sequence, read)
+	})
+}
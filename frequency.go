@@ -0,0 +1,228 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/zymatik-com/genobase/types"
+)
+
+// The confidence used for the filtering allele frequency.
+const fafConfidence = 0.95
+
+// GetAlleleFrequencyStats computes the confidence intervals and filtering
+// allele frequency (FAF95) of each allele of a variant, for every ancestry
+// group. Only alleles with known allele counts and numbers are included.
+func (db *DB) GetAlleleFrequencyStats(ctx context.Context, id int64) ([]types.AlleleFrequencyStats, error) {
+	rows, err := db.db.QueryxContext(ctx, "SELECT * FROM allele WHERE id = ? ORDER BY ref, alt, ancestry", id)
+	if err != nil {
+		return nil, fmt.Errorf("could not query alleles: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []types.AlleleFrequencyStats
+	for rows.Next() {
+		var allele types.Allele
+		if err := rows.StructScan(&allele); err != nil {
+			return nil, fmt.Errorf("could not unmarshal allele: %w", err)
+		}
+
+		if allele.AlleleCount == nil || allele.AlleleNumber == nil || *allele.AlleleNumber <= 0 {
+			continue
+		}
+
+		if len(stats) == 0 || stats[len(stats)-1].Reference != allele.Reference || stats[len(stats)-1].Alternate != allele.Alternate {
+			stats = append(stats, types.AlleleFrequencyStats{
+				ID:        allele.ID,
+				Reference: allele.Reference,
+				Alternate: allele.Alternate,
+			})
+		}
+
+		s := &stats[len(stats)-1]
+
+		ac, an := *allele.AlleleCount, *allele.AlleleNumber
+		frequency := types.AncestryFrequency{
+			Ancestry:     allele.Ancestry,
+			AlleleCount:  ac,
+			AlleleNumber: an,
+			Frequency:    float64(ac) / float64(an),
+			Wilson:       WilsonInterval(ac, an, fafConfidence),
+			Poisson:      PoissonInterval(ac, an, fafConfidence),
+			FAF95:        FilteringAlleleFrequency(ac, an, fafConfidence),
+		}
+
+		s.Ancestry = append(s.Ancestry, frequency)
+
+		if allele.Ancestry != types.AncestryGroupAll && !allele.Ancestry.Bottlenecked() && frequency.FAF95 > s.FAF95 {
+			s.FAF95 = frequency.FAF95
+			s.FAF95Ancestry = allele.Ancestry
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan alleles: %w", err)
+	}
+
+	return stats, nil
+}
+
+// WilsonInterval returns the Wilson score interval for an allele frequency,
+// given the allele count (AC) and allele number (AN).
+func WilsonInterval(ac, an int64, confidence float64) types.Interval {
+	if an <= 0 {
+		return types.Interval{}
+	}
+
+	z := math.Sqrt2 * math.Erfinv(confidence)
+	n := float64(an)
+	p := float64(ac) / n
+
+	denominator := 1 + z*z/n
+	center := (p + z*z/(2*n)) / denominator
+	halfWidth := z / denominator * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
+
+	return types.Interval{
+		Lower: math.Max(0, center-halfWidth),
+		Upper: math.Min(1, center+halfWidth),
+	}
+}
+
+// PoissonInterval returns the exact (Garwood) Poisson interval for an allele
+// frequency, given the allele count (AC) and allele number (AN). The Poisson
+// approximation is appropriate for rare alleles.
+func PoissonInterval(ac, an int64, confidence float64) types.Interval {
+	if an <= 0 {
+		return types.Interval{}
+	}
+
+	alpha := 1 - confidence
+
+	var lower float64
+	if ac > 0 {
+		// The rate at which P(X >= ac) = alpha/2.
+		lower = poissonRate(ac-1, 1-alpha/2)
+	}
+
+	// The rate at which P(X <= ac) = alpha/2.
+	upper := poissonRate(ac, alpha/2)
+
+	return types.Interval{
+		Lower: lower / float64(an),
+		Upper: math.Min(1, upper/float64(an)),
+	}
+}
+
+// FilteringAlleleFrequency returns the filtering allele frequency of an
+// allele, given the allele count (AC) and allele number (AN). That is, the
+// highest true allele frequency for which the upper bound of the confidence
+// interval of the allele count (under a Poisson distribution) is still less
+// than the observed allele count. Singletons have a filtering allele frequency
+// of zero (as in gnomAD/hail).
+// See: Whiffin et al. (2017), "Using high-resolution variant frequencies to
+// empower clinical genome interpretation", Genetics in Medicine.
+func FilteringAlleleFrequency(ac, an int64, confidence float64) float64 {
+	if ac <= 1 || an <= 0 {
+		return 0
+	}
+
+	return poissonRate(ac-1, confidence) / float64(an)
+}
+
+// poissonRate returns the rate (lambda) at which the Poisson cumulative
+// distribution function P(X <= k) is equal to p.
+func poissonRate(k int64, p float64) float64 {
+	// The CDF is monotonically decreasing in lambda, so we can bisect.
+	lo, hi := 0.0, float64(k)+20*math.Sqrt(float64(k)+1)+20
+	for i := 0; i < 200 && hi-lo > 1e-12*hi; i++ {
+		mid := (lo + hi) / 2
+		if poissonCDF(k, mid) > p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return (lo + hi) / 2
+}
+
+// poissonCDF returns P(X <= k) for a Poisson distribution with rate lambda.
+func poissonCDF(k int64, lambda float64) float64 {
+	if lambda <= 0 {
+		return 1
+	}
+
+	// P(X <= k) = Q(k+1, lambda), the regularized upper incomplete gamma function.
+	return 1 - regularizedGammaP(float64(k+1), lambda)
+}
+
+// regularizedGammaP returns the regularized lower incomplete gamma function P(a, x).
+// See: Numerical Recipes in C, 2nd Edition, Section 6.2.
+func regularizedGammaP(a, x float64) float64 {
+	const (
+		maxIterations = 1000
+		epsilon       = 1e-15
+		tiny          = 1e-300
+	)
+
+	if x <= 0 {
+		return 0
+	}
+
+	lgamma, _ := math.Lgamma(a)
+	prefactor := math.Exp(-x + a*math.Log(x) - lgamma)
+
+	if x < a+1 {
+		// Series representation.
+		sum := 1 / a
+		term := sum
+		for n := 1; n < maxIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+
+		return sum * prefactor
+	}
+
+	// Continued fraction representation (modified Lentz's method) of Q(a, x).
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < maxIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+
+	return 1 - prefactor*h
+}
@@ -0,0 +1,132 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestAlleleFrequencyStats(t *testing.T) {
+	t.Run("Wilson", func(t *testing.T) {
+		interval := genobase.WilsonInterval(5, 100, 0.95)
+		assert.InDelta(t, 0.02154, interval.Lower, 1e-5)
+		assert.InDelta(t, 0.11175, interval.Upper, 1e-5)
+
+		interval = genobase.WilsonInterval(0, 100, 0.95)
+		assert.InDelta(t, 0.0, interval.Lower, 1e-9)
+		assert.InDelta(t, 0.03699, interval.Upper, 1e-5)
+	})
+
+	t.Run("Poisson", func(t *testing.T) {
+		interval := genobase.PoissonInterval(5, 1000, 0.95)
+		assert.InDelta(t, 1.6235/1000, interval.Lower, 1e-7)
+		assert.InDelta(t, 11.6683/1000, interval.Upper, 1e-7)
+
+		interval = genobase.PoissonInterval(0, 1000, 0.95)
+		assert.Equal(t, 0.0, interval.Lower)
+		assert.InDelta(t, 3.6889/1000, interval.Upper, 1e-7)
+	})
+
+	t.Run("FAF95", func(t *testing.T) {
+		assert.Equal(t, 0.0, genobase.FilteringAlleleFrequency(1, 1000, 0.95))
+		assert.InDelta(t, 0.35536/1000, genobase.FilteringAlleleFrequency(2, 1000, 0.95), 1e-8)
+
+		// Large allele counts.
+		faf := genobase.FilteringAlleleFrequency(50000, 100000, 0.95)
+		assert.Less(t, faf, 0.5)
+		assert.Greater(t, faf, 0.49)
+	})
+
+	t.Run("Variant", func(t *testing.T) {
+		ctx := context.Background()
+
+		db, err := genobase.Open(ctx, slogt.New(t), "")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, db.Close())
+		})
+
+		count := func(n int64) *int64 { return &n }
+
+		require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+			{ID: 334, Reference: "T", Alternate: "A", Ancestry: types.AncestryGroupAll, AlleleCount: count(530), AlleleNumber: count(152194)},
+			{ID: 334, Reference: "T", Alternate: "A", Ancestry: types.AncestryGroupAfrican, AlleleCount: count(507), AlleleNumber: count(41436)},
+			{ID: 334, Reference: "T", Alternate: "A", Ancestry: types.AncestryGroupEuropean, AlleleCount: count(3), AlleleNumber: count(68036)},
+			// Bottlenecked.
+			{ID: 334, Reference: "T", Alternate: "A", Ancestry: types.AncestryGroupAmish, AlleleCount: count(100), AlleleNumber: count(912)},
+			// No counts.
+			{ID: 334, Reference: "T", Alternate: "A", Ancestry: types.AncestryGroupEastAsian, Frequency: 0.5},
+			{ID: 334, Reference: "T", Alternate: "C", Ancestry: types.AncestryGroupAll, AlleleCount: count(1), AlleleNumber: count(152230)},
+		}))
+
+		stats, err := db.GetAlleleFrequencyStats(ctx, 334)
+		require.NoError(t, err)
+
+		require.Len(t, stats, 2)
+
+		assert.Equal(t, "A", stats[0].Alternate)
+		assert.Len(t, stats[0].Ancestry, 4)
+		assert.Equal(t, types.AncestryGroupAfrican, stats[0].FAF95Ancestry)
+		assert.InDelta(t, 0.0113558, stats[0].FAF95, 1e-7)
+
+		for _, frequency := range stats[0].Ancestry {
+			assert.LessOrEqual(t, frequency.Wilson.Lower, frequency.Frequency)
+			assert.GreaterOrEqual(t, frequency.Wilson.Upper, frequency.Frequency)
+			assert.LessOrEqual(t, frequency.Poisson.Lower, frequency.Frequency)
+			assert.GreaterOrEqual(t, frequency.Poisson.Upper, frequency.Frequency)
+			assert.Less(t, frequency.FAF95, frequency.Frequency)
+		}
+
+		// Singleton.
+		assert.Equal(t, "C", stats[1].Alternate)
+		assert.Equal(t, 0.0, stats[1].FAF95)
+		assert.Equal(t, types.AncestryGroup(""), stats[1].FAF95Ancestry)
+
+		frequencies, err := db.GetAlleleFrequencies(ctx, 334)
+		require.NoError(t, err)
+
+		require.Len(t, frequencies, 2)
+		assert.Len(t, frequencies[0].Frequencies, 5)
+		assert.Equal(t, 0.5, frequencies[0].Frequencies[types.AncestryGroupEastAsian])
+
+		frequencies, err = db.GetAlleleFrequencies(ctx, 334, types.AncestryGroupAfrican, types.AncestryGroupEuropean, types.AncestryGroupAmish)
+		require.NoError(t, err)
+
+		require.Len(t, frequencies, 1)
+		assert.Len(t, frequencies[0].Frequencies, 3)
+
+		ancestry, frequency, ok := frequencies[0].MaxFrequency()
+		require.True(t, ok)
+		assert.Equal(t, types.AncestryGroupAmish, ancestry)
+		assert.InDelta(t, 100.0/912, frequency, 1e-9)
+
+		ancestry, frequency, ok = frequencies[0].PopMax()
+		require.True(t, ok)
+		assert.Equal(t, types.AncestryGroupAfrican, ancestry)
+		assert.InDelta(t, 507.0/41436, frequency, 1e-9)
+
+		frequencies, err = db.GetAlleleFrequencies(ctx, 334, types.AncestryGroupAll)
+		require.NoError(t, err)
+
+		require.Len(t, frequencies, 2)
+		_, _, ok = frequencies[1].PopMax()
+		assert.False(t, ok)
+	})
+}
@@ -15,10 +15,13 @@ package genobase
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
@@ -26,8 +29,16 @@ import (
 	"github.com/zymatik-com/genobase/types"
 )
 
+// The maximum number of IDs to look up in a single query (SQLite limits the
+// number of bound parameters per statement).
+const lookupBatchSize = 900
+
 type DB struct {
-	db *sqlx.DB
+	logger *slog.Logger
+	db     *sqlx.DB
+	// sequenceMu guards sequence, which can be set while the database is in use.
+	sequenceMu sync.RWMutex
+	sequence   SequenceSource
 }
 
 //go:embed migrations/*.sql
@@ -56,17 +67,54 @@ func Open(ctx context.Context, logger *slog.Logger, dbPath string, opts ...Optio
 		goose.SetBaseFS(embedMigrations)
 
 		if err := goose.SetDialect("sqlite3"); err != nil {
+			_ = db.Close()
 			return nil, fmt.Errorf("could not set dialect: %w", err)
 		}
 
 		if err := goose.UpContext(ctx, db.DB, "migrations"); err != nil {
+			_ = db.Close()
 			return nil, fmt.Errorf("could not apply migrations: %w", err)
 		}
 	}
 
-	return &DB{
-		db: db,
-	}, nil
+	gdb := &DB{
+		logger: logger,
+		db:     db,
+	}
+
+	// Normalize against the imported GRCh38 sequence, if there is one.
+	hasSequence, err := gdb.hasSequence(ctx, types.ReferenceGRCh38)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	if hasSequence {
+		gdb.sequence = gdb
+	}
+
+	return gdb, nil
+}
+
+// hasSequence returns true if the sequence of a reference genome assembly has
+// been imported. Read-only databases are not migrated, so may predate the
+// sequence table.
+func (db *DB) hasSequence(ctx context.Context, reference types.Reference) (bool, error) {
+	var hasTable bool
+	if err := db.db.GetContext(ctx, &hasTable, "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'sequence')"); err != nil {
+		return false, fmt.Errorf("could not query tables: %w", err)
+	}
+
+	if !hasTable {
+		return false, nil
+	}
+
+	var hasSequence bool
+	if err := db.db.GetContext(ctx, &hasSequence, "SELECT EXISTS (SELECT 1 FROM sequence WHERE ref = ?)", reference); err != nil {
+		return false, fmt.Errorf("could not query sequences: %w", err)
+	}
+
+	return hasSequence, nil
 }
 
 func (db *DB) Close() error {
@@ -93,32 +141,202 @@ func (db *DB) GetVariant(ctx context.Context, id int64) (*types.Variant, error)
 		return nil, fmt.Errorf("could not unmarshal variant: %w", err)
 	}
 
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("could not close rows: %w", err)
+	}
+
+	variant.Positions, err = db.GetVariantPositions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	return &variant, nil
 }
 
+// GetVariantPositions returns the position of a variant on each reference
+// genome assembly it is known on.
+func (db *DB) GetVariantPositions(ctx context.Context, id int64) (map[types.Reference]types.Locus, error) {
+	var rows []types.VariantPosition
+	if err := db.db.SelectContext(ctx, &rows, "SELECT * FROM variant_position WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("could not query variant positions: %w", err)
+	}
+
+	positions := make(map[types.Reference]types.Locus, len(rows))
+	for _, row := range rows {
+		positions[row.Reference] = types.Locus{
+			Chromosome: row.Chromosome,
+			Position:   row.Position,
+		}
+	}
+
+	return positions, nil
+}
+
+// GetVariantsByIDs looks up a list of variants by their ID (RSID), returning
+// the variants found keyed by ID. IDs that are not found are omitted.
+func (db *DB) GetVariantsByIDs(ctx context.Context, ids []int64) (map[int64]types.Variant, error) {
+	variants := make(map[int64]types.Variant, len(ids))
+
+	for start := 0; start < len(ids); start += lookupBatchSize {
+		batch := ids[start:min(start+lookupBatchSize, len(ids))]
+
+		query, args, err := sqlx.In("SELECT * FROM variant WHERE id IN (?)", batch)
+		if err != nil {
+			return nil, fmt.Errorf("could not build query: %w", err)
+		}
+
+		rows, err := db.db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("could not query variants: %w", err)
+		}
+
+		err = forEachVariant(ctx, rows, func(variant *types.Variant) error {
+			variants[variant.ID] = *variant
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return variants, nil
+}
+
 func (db *DB) GetVariants(ctx context.Context, chromosome types.Chromosome, position int64) ([]types.Variant, error) {
-	rows, err := db.db.QueryxContext(ctx, "SELECT * FROM variant WHERE chromosome = ? AND position = ?",
-		chromosome, position)
+	var variants []types.Variant
+	err := db.ForEachVariant(ctx, chromosome, position, func(variant *types.Variant) error {
+		variants = append(variants, *variant)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("could not query variants: %w", err)
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+// ForEachVariant calls fn for each variant at the given position on a chromosome.
+// Variants are streamed from the database, rather than materialized in memory.
+// Lookups on chromosomes X and Y also include variants stored on the
+// pseudoautosomal regions (ChrPAR and ChrPAR2).
+// If fn returns an error (or the context is cancelled) iteration stops and the
+// error is returned.
+func (db *DB) ForEachVariant(ctx context.Context, chromosome types.Chromosome, position int64, fn func(variant *types.Variant) error) error {
+	condition, args := positionCondition(types.ReferenceGRCh38, "variant", chromosome, position)
+
+	rows, err := db.db.QueryxContext(ctx, "SELECT * FROM variant WHERE "+condition, args...)
+	if err != nil {
+		return fmt.Errorf("could not query variants: %w", err)
 	}
-	defer rows.Close()
 
+	return forEachVariant(ctx, rows, fn)
+}
+
+// GetVariantsInRange returns all the variants on a chromosome within the 0-based,
+// half-open interval [start, end) (as in BED files), ordered by position.
+// If any classes are specified, only variants of those classes are returned.
+func (db *DB) GetVariantsInRange(ctx context.Context, chromosome types.Chromosome, start, end int64, classes ...types.VariantClass) ([]types.Variant, error) {
 	var variants []types.Variant
+	err := db.ForEachVariantInRange(ctx, chromosome, start, end, func(variant *types.Variant) error {
+		variants = append(variants, *variant)
+		return nil
+	}, classes...)
+	if err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+// ForEachVariantInRange calls fn for each variant on a chromosome within the
+// 0-based, half-open interval [start, end), in order of position. This can be
+// used to scan entire chromosomes, as variants are streamed from the database.
+// If any classes are specified, only variants of those classes are returned.
+// Ranges on chromosomes X and Y also include variants stored on the
+// pseudoautosomal regions (ChrPAR and ChrPAR2), in order of their equivalent
+// position on the sex chromosome.
+// If fn returns an error (or the context is cancelled) iteration stops and the
+// error is returned.
+func (db *DB) ForEachVariantInRange(ctx context.Context, chromosome types.Chromosome, start, end int64, fn func(variant *types.Variant) error, classes ...types.VariantClass) error {
+	for _, segment := range variantRanges(chromosome, start, end) {
+		// Variant positions are 1-based.
+		condition, args := rangeCondition(segment)
+		query := "SELECT * FROM variant WHERE " + condition
+		if len(classes) > 0 {
+			var err error
+			query, args, err = sqlx.In(query+" AND class IN (?)", append(args, classes)...)
+			if err != nil {
+				return fmt.Errorf("could not build query: %w", err)
+			}
+		}
+
+		order := " ORDER BY position, id"
+		if len(segment) > 1 {
+			order = " ORDER BY position + CASE WHEN chromosome = ? THEN ? ELSE 0 END, id"
+			args = append(args, segment[1].chromosome, segment[1].offset)
+		}
+
+		rows, err := db.db.QueryxContext(ctx, query+order, args...)
+		if err != nil {
+			return fmt.Errorf("could not query variants: %w", err)
+		}
+
+		if err := forEachVariant(ctx, rows, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetVariantsInRegions returns all the variants within any of the regions, in
+// the order of the regions. Variants in overlapping regions are only returned once.
+// If any classes are specified, only variants of those classes are returned.
+func (db *DB) GetVariantsInRegions(ctx context.Context, regions []types.Region, classes ...types.VariantClass) ([]types.Variant, error) {
+	seen := make(map[int64]bool)
+
+	var variants []types.Variant
+	for _, region := range regions {
+		regionVariants, err := db.GetVariantsInRange(ctx, region.Chromosome, region.Start, region.End, classes...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, variant := range regionVariants {
+			if !seen[variant.ID] {
+				seen[variant.ID] = true
+				variants = append(variants, variant)
+			}
+		}
+	}
+
+	return variants, nil
+}
+
+// forEachVariant calls fn for each variant in the result set, closing it once done.
+func forEachVariant(ctx context.Context, rows *sqlx.Rows, fn func(variant *types.Variant) error) error {
+	defer rows.Close()
+
 	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var variant types.Variant
 		if err := rows.StructScan(&variant); err != nil {
-			return nil, fmt.Errorf("could not unmarshal variant: %w", err)
+			return fmt.Errorf("could not unmarshal variant: %w", err)
 		}
 
-		variants = append(variants, variant)
+		if err := fn(&variant); err != nil {
+			return err
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not scan variants: %w", err)
+		return fmt.Errorf("could not scan variants: %w", err)
 	}
 
-	return variants, nil
+	return nil
 }
 
 func (db *DB) StoreVariants(ctx context.Context, variants []types.Variant) error {
@@ -138,10 +356,39 @@ func (db *DB) StoreVariants(ctx context.Context, variants []types.Variant) error
 	}
 	defer stmt.Close()
 
+	positionStmt, err := tx.PrepareNamedContext(ctx, variantPositionUpsert)
+	if err != nil {
+		return fmt.Errorf("could not prepare statement: %w", err)
+	}
+	defer positionStmt.Close()
+
 	for _, variant := range variants {
 		if _, err := stmt.ExecContext(ctx, variant); err != nil {
 			return fmt.Errorf("could not store variant: %w", err)
 		}
+
+		positions := []types.VariantPosition{{
+			ID:         variant.ID,
+			Reference:  types.ReferenceGRCh38,
+			Chromosome: variant.Chromosome,
+			Position:   variant.Position,
+		}}
+		for reference, locus := range variant.Positions {
+			if reference != types.ReferenceGRCh38 {
+				positions = append(positions, types.VariantPosition{
+					ID:         variant.ID,
+					Reference:  reference,
+					Chromosome: locus.Chromosome,
+					Position:   locus.Position,
+				})
+			}
+		}
+
+		for _, position := range positions {
+			if _, err := positionStmt.ExecContext(ctx, position); err != nil {
+				return fmt.Errorf("could not store variant position: %w", err)
+			}
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -151,7 +398,129 @@ func (db *DB) StoreVariants(ctx context.Context, variants []types.Variant) error
 	return nil
 }
 
+const variantPositionUpsert = `INSERT INTO variant_position (id, ref, chromosome, position)
+	VALUES (:id, :ref, :chromosome, :position)
+	ON CONFLICT(id, ref) DO UPDATE SET
+		chromosome = excluded.chromosome,
+		position = excluded.position`
+
+// StoreVariantPositions stores (or updates) the positions of variants on
+// reference genome assemblies other than GRCh38 (GRCh38 positions are stored
+// with StoreVariants). The variants must already be stored.
+func (db *DB) StoreVariantPositions(ctx context.Context, positions []types.VariantPosition) error {
+	tx, err := db.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareNamedContext(ctx, variantPositionUpsert)
+	if err != nil {
+		return fmt.Errorf("could not prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, position := range positions {
+		if position.Reference == types.ReferenceGRCh38 {
+			return fmt.Errorf("GRCh38 positions must be stored with the variant")
+		}
+
+		var stored bool
+		if err := tx.GetContext(ctx, &stored, "SELECT EXISTS (SELECT 1 FROM variant WHERE id = ?)", position.ID); err != nil {
+			return fmt.Errorf("could not query variant: %w", err)
+		}
+
+		if !stored {
+			return fmt.Errorf("variant %d is not stored: %w", position.ID, os.ErrNotExist)
+		}
+
+		if _, err := stmt.ExecContext(ctx, position); err != nil {
+			return fmt.Errorf("could not store variant position: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllele looks up an allele of a variant. If a sequence source has been set,
+// and there's no exact match, the alleles are normalized against the variant's
+// stored position and looked up again. This finds indels that are padded or not
+// left-aligned (eg. as read from a VCF file).
 func (db *DB) GetAllele(ctx context.Context, id int64, reference, alternate string, ancestry types.AncestryGroup) (*types.Allele, error) {
+	allele, err := db.getAllele(ctx, id, reference, alternate, ancestry)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return allele, err
+	}
+
+	normalizedRef, normalizedAlt, ok, normalizeErr := db.normalizeAlleles(ctx, id, reference, alternate)
+	if normalizeErr != nil {
+		return nil, fmt.Errorf("could not normalize alleles: %w", normalizeErr)
+	}
+
+	if !ok || (normalizedRef == reference && normalizedAlt == alternate) {
+		return nil, err
+	}
+
+	return db.getAllele(ctx, id, normalizedRef, normalizedAlt, ancestry)
+}
+
+// normalizeAlleles normalizes the alleles of a variant, whose position is not
+// known, other than that they should normalize to the variant's stored position.
+// Each nearby position at which the reference allele matches the sequence is
+// tried in turn. False is returned if the alleles could not be normalized.
+func (db *DB) normalizeAlleles(ctx context.Context, id int64, reference, alternate string) (string, string, bool, error) {
+	seq := db.sequenceSource()
+	if seq == nil || reference == "" || alternate == "" {
+		return "", "", false, nil
+	}
+
+	variant, err := db.GetVariant(ctx, id)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", "", false, nil
+		}
+
+		return "", "", false, err
+	}
+
+	// Padding bases place the alleles before the variant, and indels that are
+	// not left-aligned are after it.
+	for position := max(1, variant.Position-int64(len(reference))+1); position <= variant.Position+normalizeWindow; position++ {
+		expected, err := seq.GetSequence(ctx, types.ReferenceGRCh38, variant.Chromosome, position-1, position-1+int64(len(reference)))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return "", "", false, nil
+			}
+
+			// Past the end of the chromosome.
+			break
+		}
+
+		if !strings.EqualFold(expected, reference) {
+			continue
+		}
+
+		normalizedPosition, normalizedRef, normalizedAlts, err := db.normalizeVariant(ctx, types.ReferenceGRCh38, variant.Chromosome, position, reference, alternate)
+		if err != nil {
+			return "", "", false, err
+		}
+
+		if normalizedPosition == variant.Position {
+			return normalizedRef, normalizedAlts[0], true, nil
+		}
+	}
+
+	return "", "", false, nil
+}
+
+// getAllele looks up an allele of a variant, exactly as it is stored.
+func (db *DB) getAllele(ctx context.Context, id int64, reference, alternate string, ancestry types.AncestryGroup) (*types.Allele, error) {
 	rows, err := db.db.QueryxContext(ctx, "SELECT * FROM allele WHERE id = ? AND ref = ? AND alt = ? AND ancestry = ? LIMIT 1",
 		id, reference, alternate, ancestry)
 	if err != nil {
@@ -175,46 +544,298 @@ func (db *DB) GetAllele(ctx context.Context, id int64, reference, alternate stri
 	return &allele, nil
 }
 
+// GetAlleleByPosition looks up an allele by the position of its variant,
+// rather than its RSID (eg. for variants without an RSID). Positions on
+// assemblies other than GRCh38 are looked up using the stored variant positions
+// (see StoreVariantPositions), falling back to lifting over to GRCh38. If a
+// sequence source has been set, GRCh38 variants are normalized before lookup
+// (variants on other assemblies are normalized if their sequence is imported).
+// Lookups on chromosomes X and Y also find variants stored on the
+// pseudoautosomal regions (ChrPAR and ChrPAR2).
+func (db *DB) GetAlleleByPosition(ctx context.Context, assembly types.Reference, chromosome types.Chromosome, position int64,
+	reference, alternate string, ancestry types.AncestryGroup) (*types.Allele, error) {
+	if assembly != types.ReferenceGRCh38 {
+		// Stored positions on other assemblies are normalized against their
+		// imported sequence (if there is one).
+		normalizedPosition, normalizedRef, normalizedAlts, err := db.normalizeVariant(ctx, assembly, chromosome, position, reference, alternate)
+		if err != nil {
+			return nil, fmt.Errorf("could not normalize variant: %w", err)
+		}
+
+		allele, err := db.getAlleleAt(ctx, assembly, chromosome, normalizedPosition, normalizedRef, normalizedAlts[0], ancestry)
+		if err == nil || !errors.Is(err, os.ErrNotExist) {
+			return allele, err
+		}
+
+		var strand string
+		if span := int64(len(reference)); span <= 1 {
+			lifted, err := db.Liftover(ctx, assembly, types.ReferenceGRCh38, chromosome, position)
+			if err != nil {
+				return nil, fmt.Errorf("could not lift over position: %w", err)
+			}
+
+			chromosome, position, strand = lifted.Chromosome, lifted.Position, lifted.Strand
+		} else {
+			regions, err := db.LiftoverRegion(ctx, assembly, types.ReferenceGRCh38, types.Region{
+				Chromosome: chromosome,
+				Start:      position - 1,
+				End:        position - 1 + span,
+			}, MinMatch(1))
+			if err != nil {
+				return nil, fmt.Errorf("could not lift over position: %w", err)
+			}
+
+			// All the reference bases must map contiguously.
+			if len(regions) != 1 || regions[0].End-regions[0].Start != span {
+				return nil, fmt.Errorf("could not lift over position: %w", ErrInGap)
+			}
+
+			chromosome, position, strand = regions[0].Chromosome, regions[0].Start+1, regions[0].Strand
+		}
+
+		if strand == "-" {
+			if len(reference) != len(alternate) {
+				return nil, fmt.Errorf("indels on the reverse strand cannot be looked up by position")
+			}
+
+			reference, alternate = types.ReverseComplement(reference), types.ReverseComplement(alternate)
+		}
+	}
+
+	position, reference, alternates, err := db.normalizeVariant(ctx, types.ReferenceGRCh38, chromosome, position, reference, alternate)
+	if err != nil {
+		return nil, fmt.Errorf("could not normalize variant: %w", err)
+	}
+	alternate = alternates[0]
+
+	return db.getAlleleAt(ctx, types.ReferenceGRCh38, chromosome, position, reference, alternate, ancestry)
+}
+
+// getAlleleAt looks up an allele by the stored position of its variant on a
+// reference genome assembly.
+func (db *DB) getAlleleAt(ctx context.Context, assembly types.Reference, chromosome types.Chromosome, position int64,
+	reference, alternate string, ancestry types.AncestryGroup) (*types.Allele, error) {
+	condition, args := positionCondition(assembly, "variant_position", chromosome, position)
+
+	// Prefer real RSIDs over synthetic IDs.
+	rows, err := db.db.QueryxContext(ctx, `SELECT allele.* FROM allele
+		JOIN variant_position ON variant_position.id = allele.id
+		WHERE variant_position.ref = ? AND `+condition+`
+		AND allele.ref = ? AND allele.alt = ? AND allele.ancestry = ?
+		ORDER BY allele.id DESC LIMIT 1`,
+		append(append([]any{assembly}, args...), reference, alternate, ancestry)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query alleles: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("no allele found: %w", os.ErrNotExist)
+	}
+
+	var allele types.Allele
+	if err := rows.StructScan(&allele); err != nil {
+		return nil, fmt.Errorf("could not unmarshal allele: %w", err)
+	}
+
+	return &allele, nil
+}
+
 func (db *DB) GetAlleles(ctx context.Context, id int64) ([]types.Allele, error) {
+	var alleles []types.Allele
+	err := db.ForEachAllele(ctx, id, func(allele *types.Allele) error {
+		alleles = append(alleles, *allele)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return alleles, nil
+}
+
+// ForEachAllele calls fn for each allele (across all populations) of a variant.
+// If fn returns an error (or the context is cancelled) iteration stops and the
+// error is returned.
+func (db *DB) ForEachAllele(ctx context.Context, id int64, fn func(allele *types.Allele) error) error {
 	rows, err := db.db.QueryxContext(ctx, "SELECT * FROM allele WHERE id = ? AND ancestry = 'ALL'", id)
+	if err != nil {
+		return fmt.Errorf("could not query alleles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var allele types.Allele
+		if err := rows.StructScan(&allele); err != nil {
+			return fmt.Errorf("could not unmarshal allele: %w", err)
+		}
+
+		if err := fn(&allele); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not scan alleles: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlleleFrequencies returns the frequency of each allele of a variant
+// across all ancestry groups (or only the given ancestry groups).
+func (db *DB) GetAlleleFrequencies(ctx context.Context, id int64, ancestries ...types.AncestryGroup) ([]types.AlleleFrequencies, error) {
+	query, args := "SELECT * FROM allele WHERE id = ? ORDER BY ref, alt", []any{id}
+	if len(ancestries) > 0 {
+		var err error
+		query, args, err = sqlx.In("SELECT * FROM allele WHERE id = ? AND ancestry IN (?) ORDER BY ref, alt", id, ancestries)
+		if err != nil {
+			return nil, fmt.Errorf("could not build query: %w", err)
+		}
+	}
+
+	rows, err := db.db.QueryxContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not query alleles: %w", err)
 	}
 	defer rows.Close()
 
-	var alleles []types.Allele
+	var frequencies []types.AlleleFrequencies
 	for rows.Next() {
 		var allele types.Allele
 		if err := rows.StructScan(&allele); err != nil {
 			return nil, fmt.Errorf("could not unmarshal allele: %w", err)
 		}
 
-		alleles = append(alleles, allele)
+		if len(frequencies) == 0 || frequencies[len(frequencies)-1].Reference != allele.Reference ||
+			frequencies[len(frequencies)-1].Alternate != allele.Alternate {
+			frequencies = append(frequencies, types.AlleleFrequencies{
+				ID:          allele.ID,
+				Reference:   allele.Reference,
+				Alternate:   allele.Alternate,
+				Frequencies: make(map[types.AncestryGroup]float64),
+			})
+		}
+
+		frequencies[len(frequencies)-1].Frequencies[allele.Ancestry] = allele.Frequency
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("could not scan alleles: %w", err)
 	}
 
+	return frequencies, nil
+}
+
+// GetAllelesByIDs looks up the alleles of a list of variants by their ID (RSID),
+// returning the alleles keyed by variant ID. If no ancestry groups are given,
+// only alleles for all populations (AncestryGroupAll) are returned.
+func (db *DB) GetAllelesByIDs(ctx context.Context, ids []int64, ancestries ...types.AncestryGroup) (map[int64][]types.Allele, error) {
+	if len(ancestries) == 0 {
+		ancestries = []types.AncestryGroup{types.AncestryGroupAll}
+	}
+
+	ancestries = slices.Clone(ancestries)
+	slices.Sort(ancestries)
+	ancestries = slices.Compact(ancestries)
+
+	// Leave room in each query for at least a reasonable number of IDs.
+	if len(ancestries) > lookupBatchSize/2 {
+		return nil, fmt.Errorf("too many ancestry groups: %d", len(ancestries))
+	}
+
+	alleles := make(map[int64][]types.Allele, len(ids))
+
+	batchSize := lookupBatchSize - len(ancestries)
+	for start := 0; start < len(ids); start += batchSize {
+		batch := ids[start:min(start+batchSize, len(ids))]
+
+		query, args, err := sqlx.In("SELECT * FROM allele WHERE id IN (?) AND ancestry IN (?) ORDER BY id", batch, ancestries)
+		if err != nil {
+			return nil, fmt.Errorf("could not build query: %w", err)
+		}
+
+		rows, err := db.db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("could not query alleles: %w", err)
+		}
+
+		for rows.Next() {
+			var allele types.Allele
+			if err := rows.StructScan(&allele); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("could not unmarshal allele: %w", err)
+			}
+
+			alleles[allele.ID] = append(alleles[allele.ID], allele)
+		}
+
+		if err := rows.Err(); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("could not scan alleles: %w", err)
+		}
+
+		if err := rows.Close(); err != nil {
+			return nil, fmt.Errorf("could not close rows: %w", err)
+		}
+	}
+
 	return alleles, nil
 }
 
-func (db *DB) StoreAlleles(ctx context.Context, alleles []types.Allele) error {
+// StoreAlleles stores (or updates) alleles. If the allele count and number
+// of an allele are known, its frequency is computed from them.
+func (db *DB) StoreAlleles(ctx context.Context, alleles []types.Allele, opts ...StoreOption) error {
+	var options storeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.validate {
+		mismatches, err := db.ValidateAlleles(ctx, alleles)
+		if err != nil {
+			return err
+		}
+
+		if len(mismatches) > 0 {
+			return &ReferenceMismatchError{Mismatches: mismatches}
+		}
+	}
+
 	tx, err := db.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("could not start transaction: %w", err)
 	}
 
-	stmt, err := tx.PrepareNamedContext(ctx, `INSERT INTO allele (id, ref, alt, ancestry, frequency) 
-	  VALUES (:id, :ref, :alt, :ancestry, :frequency) 
+	stmt, err := tx.PrepareNamedContext(ctx, `INSERT INTO allele (id, ref, alt, ancestry, frequency,
+	    allele_count, allele_number, homozygote_count, hemizygote_count) 
+	  VALUES (:id, :ref, :alt, :ancestry, :frequency,
+	    :allele_count, :allele_number, :homozygote_count, :hemizygote_count) 
 	  ON CONFLICT(id, ref, alt, ancestry) DO UPDATE SET
-		frequency = excluded.frequency`)
+		frequency = excluded.frequency,
+		allele_count = excluded.allele_count,
+		allele_number = excluded.allele_number,
+		homozygote_count = excluded.homozygote_count,
+		hemizygote_count = excluded.hemizygote_count`)
 	if err != nil {
 		return fmt.Errorf("could not prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
 	for _, allele := range alleles {
+		// Prefer the exact frequency if we have the counts.
+		if allele.AlleleCount != nil && allele.AlleleNumber != nil && *allele.AlleleNumber > 0 {
+			allele.Frequency = float64(*allele.AlleleCount) / float64(*allele.AlleleNumber)
+		}
+
 		if _, err := stmt.ExecContext(ctx, allele); err != nil {
 			return fmt.Errorf("could not store allele: %w", err)
 		}
@@ -228,32 +849,55 @@ func (db *DB) StoreAlleles(ctx context.Context, alleles []types.Allele) error {
 }
 
 func (db *DB) KnownAlleles(ctx context.Context) (map[int64]bool, error) {
-	rows, err := db.db.QueryxContext(ctx, "SELECT DISTINCT id FROM allele")
+	entries := make(map[int64]bool)
+	err := db.ForEachKnownAllele(ctx, func(id int64) error {
+		entries[id] = true
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("could not query alleles: %w", err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ForEachKnownAllele calls fn with the ID of each variant that has alleles, in
+// ascending order. Unlike KnownAlleles, the IDs are streamed from the database
+// rather than collected into a map.
+// If fn returns an error (or the context is cancelled) iteration stops and the
+// error is returned.
+func (db *DB) ForEachKnownAllele(ctx context.Context, fn func(id int64) error) error {
+	rows, err := db.db.QueryxContext(ctx, "SELECT DISTINCT id FROM allele ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("could not query alleles: %w", err)
 	}
 	defer rows.Close()
 
-	entries := make(map[int64]bool)
 	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id int64
 		if err := rows.Scan(&id); err != nil {
-			return nil, fmt.Errorf("could not scan allele: %w", err)
+			return fmt.Errorf("could not scan allele: %w", err)
 		}
 
-		entries[id] = true
+		if err := fn(id); err != nil {
+			return err
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not scan alleles: %w", err)
+		return fmt.Errorf("could not scan alleles: %w", err)
 	}
 
-	return entries, nil
+	return nil
 }
 
-func (db *DB) GetChain(ctx context.Context, from types.Reference, chromosome types.Chromosome, position int64) (*types.Chain, error) {
-	rows, err := db.db.QueryxContext(ctx, "SELECT * FROM liftover_chain WHERE ref = ? AND ref_name = ? AND ref_start <= ? AND ref_end >= ? LIMIT 1",
-		from, chromosome, position, position)
+func (db *DB) GetChain(ctx context.Context, from, to types.Reference, chromosome types.Chromosome, position int64) (*types.Chain, error) {
+	rows, err := db.db.QueryxContext(ctx, "SELECT * FROM liftover_chain WHERE ref = ? AND query_ref = ? AND ref_name = ? AND ref_start <= ? AND ref_end >= ? LIMIT 1",
+		from, to, chromosome, position, position)
 	if err != nil {
 		return nil, fmt.Errorf("could not query chains: %w", err)
 	}
@@ -275,17 +919,21 @@ func (db *DB) GetChain(ctx context.Context, from types.Reference, chromosome typ
 	return &chain, nil
 }
 
-func (db *DB) StoreChain(ctx context.Context, from types.Reference, chain *types.Chain) (int64, error) {
+func (db *DB) StoreChain(ctx context.Context, from, to types.Reference, chain *types.Chain) (int64, error) {
+	stored := *chain
+	stored.Ref = from
+	stored.QueryRef = to
+
 	result, err := db.db.NamedExecContext(ctx, `
 		INSERT INTO liftover_chain (
 			score, ref, ref_name, ref_size, ref_strand, 
-			ref_start, ref_end, query_name, query_size, 
+			ref_start, ref_end, query_ref, query_name, query_size, 
 			query_strand, query_start, query_end
 		) VALUES (
 			:score, :ref, :ref_name, :ref_size, :ref_strand, 
-			:ref_start, :ref_end, :query_name, :query_size, 
+			:ref_start, :ref_end, :query_ref, :query_name, :query_size, 
 			:query_strand, :query_start, :query_end
-		)`, chain)
+		)`, stored)
 	if err != nil {
 		return -1, fmt.Errorf("could not store chain: %w", err)
 	}
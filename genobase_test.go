@@ -14,6 +14,11 @@ package genobase_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/neilotoole/slogt"
@@ -59,6 +64,40 @@ func TestGenobase(t *testing.T) {
 		assert.Equal(t, variant.Class, types.VariantClassSNV)
 	})
 
+	t.Run("VariantsInRange", func(t *testing.T) {
+		require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+			{ID: 100, Chromosome: types.Chr22, Position: 19963700, Class: types.VariantClassSNV},
+			{ID: 101, Chromosome: types.Chr22, Position: 19963800, Class: types.VariantClassDEL},
+			{ID: 102, Chromosome: types.Chr22, Position: 19964000, Class: types.VariantClassINS},
+		}))
+
+		variants, err := db.GetVariantsInRange(ctx, types.Chr22, 19963699, 19963800)
+		require.NoError(t, err)
+
+		require.Len(t, variants, 3)
+		assert.Equal(t, int64(100), variants[0].ID)
+		assert.Equal(t, int64(4680), variants[1].ID)
+		assert.Equal(t, int64(101), variants[2].ID)
+
+		variants, err = db.GetVariantsInRange(ctx, types.Chr22, 19963700, 19964000, types.VariantClassDEL, types.VariantClassINS)
+		require.NoError(t, err)
+
+		require.Len(t, variants, 2)
+		assert.Equal(t, int64(101), variants[0].ID)
+		assert.Equal(t, int64(102), variants[1].ID)
+
+		variants, err = db.GetVariantsInRegions(ctx, []types.Region{
+			{Chromosome: types.Chr11, Start: 5227000, End: 5227010},
+			{Chromosome: types.Chr22, Start: 19963740, End: 19963800},
+			{Chromosome: types.Chr22, Start: 19963700, End: 19963750},
+		}, types.VariantClassSNV)
+		require.NoError(t, err)
+
+		require.Len(t, variants, 2)
+		assert.Equal(t, int64(334), variants[0].ID)
+		assert.Equal(t, int64(4680), variants[1].ID)
+	})
+
 	t.Run("Alleles", func(t *testing.T) {
 		alleles := []types.Allele{
 			{
@@ -117,6 +156,110 @@ func TestGenobase(t *testing.T) {
 		assert.NotContains(t, KnownAlleles, int64(1))
 	})
 
+	t.Run("Streaming", func(t *testing.T) {
+		length, err := db.GetChromosomeLength(ctx, types.ReferenceGRCh38, types.Chr22)
+		require.NoError(t, err)
+
+		var ids []int64
+		err = db.ForEachVariantInRange(ctx, types.Chr22, 0, length, func(variant *types.Variant) error {
+			ids = append(ids, variant.ID)
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []int64{100, 4680, 101, 102}, ids)
+
+		// Stop early.
+		errStop := errors.New("stop")
+
+		ids = nil
+		err = db.ForEachVariantInRange(ctx, types.Chr22, 0, length, func(variant *types.Variant) error {
+			ids = append(ids, variant.ID)
+			if len(ids) == 2 {
+				return errStop
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, errStop)
+
+		assert.Equal(t, []int64{100, 4680}, ids)
+
+		var alleles []types.Allele
+		err = db.ForEachAllele(ctx, 4680, func(allele *types.Allele) error {
+			alleles = append(alleles, *allele)
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Len(t, alleles, 1)
+		assert.Equal(t, "G", alleles[0].Reference)
+
+		ids = nil
+		err = db.ForEachKnownAllele(ctx, func(id int64) error {
+			ids = append(ids, id)
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []int64{334, 4680}, ids)
+
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err = db.ForEachKnownAllele(cancelledCtx, func(id int64) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		// Enough IDs to span multiple queries.
+		ids := []int64{4680, 334}
+		for id := int64(1_000_000); len(ids) < 2000; id++ {
+			ids = append(ids, id)
+		}
+
+		variants, err := db.GetVariantsByIDs(ctx, ids)
+		require.NoError(t, err)
+
+		require.Len(t, variants, 2)
+		assert.Equal(t, types.Chr22, variants[4680].Chromosome)
+		assert.Equal(t, types.Chr11, variants[334].Chromosome)
+
+		alleles, err := db.GetAllelesByIDs(ctx, ids)
+		require.NoError(t, err)
+
+		require.Len(t, alleles, 2)
+		require.Len(t, alleles[4680], 1)
+		assert.Equal(t, "A", alleles[4680][0].Alternate)
+		require.Len(t, alleles[334], 1)
+		assert.Equal(t, 0.003480, alleles[334][0].Frequency)
+
+		alleles, err = db.GetAllelesByIDs(ctx, ids, types.AncestryGroupAfrican)
+		require.NoError(t, err)
+
+		assert.Empty(t, alleles)
+
+		// Duplicate ancestry groups are only looked up once.
+		ancestries := make([]types.AncestryGroup, 1000)
+		for i := range ancestries {
+			ancestries[i] = types.AncestryGroupAll
+		}
+
+		alleles, err = db.GetAllelesByIDs(ctx, ids, ancestries...)
+		require.NoError(t, err)
+
+		require.Len(t, alleles, 2)
+		require.Len(t, alleles[334], 1)
+
+		for i := range ancestries {
+			ancestries[i] = types.AncestryGroup(fmt.Sprintf("X%d", i))
+		}
+
+		_, err = db.GetAllelesByIDs(ctx, ids, ancestries...)
+		assert.Error(t, err)
+	})
+
 	t.Run("Liftover", func(t *testing.T) {
 		chain := &types.Chain{
 			Score:       1,
@@ -133,7 +276,7 @@ func TestGenobase(t *testing.T) {
 			QueryEnd:    297968,
 		}
 
-		chainID, err := db.StoreChain(ctx, types.ReferenceGRCh37, chain)
+		chainID, err := db.StoreChain(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, chain)
 		require.NoError(t, err)
 
 		assert.Equal(t, chainID, int64(1))
@@ -153,12 +296,13 @@ func TestGenobase(t *testing.T) {
 		err = db.StoreAlignments(ctx, chainID, alignments)
 		require.NoError(t, err)
 
-		retrievedChain, err := db.GetChain(ctx, types.ReferenceGRCh37, "1", 217480)
+		retrievedChain, err := db.GetChain(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, "1", 217480)
 		require.NoError(t, err)
 
 		assert.Equal(t, retrievedChain.ID, chainID)
 		assert.Equal(t, retrievedChain.Score, int64(1))
 		assert.Equal(t, retrievedChain.Ref, types.ReferenceGRCh37)
+		assert.Equal(t, retrievedChain.QueryRef, types.ReferenceGRCh38)
 		assert.Equal(t, retrievedChain.RefName, types.Chr1)
 		assert.Equal(t, retrievedChain.RefSize, int64(249250621))
 		assert.Equal(t, retrievedChain.RefStrand, "+")
@@ -180,3 +324,82 @@ func TestGenobase(t *testing.T) {
 		assert.Equal(t, retrievedAlignment.Size, int64(40302))
 	})
 }
+
+func TestGetAlleleByPosition(t *testing.T) {
+	ctx := context.Background()
+
+	db := openLiftoverDB(t)
+
+	// Variants are stored with their GRCh38 positions.
+	require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+		{ID: 1, Chromosome: types.Chr1, Position: 10001, Class: types.VariantClassSNV},
+		{ID: 2, Chromosome: types.Chr3, Position: 5559, Class: types.VariantClassSNV},
+		{ID: 3, Chromosome: types.Chr3, Position: 5558, Class: types.VariantClassMNV},
+		{ID: 4, Chromosome: types.Chr2, Position: 2099, Class: types.VariantClassMNV},
+	}))
+
+	require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+		{ID: 1, Reference: "G", Alternate: "A", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+		{ID: 2, Reference: "G", Alternate: "A", Ancestry: types.AncestryGroupAll, Frequency: 0.2},
+		{ID: 3, Reference: "TG", Alternate: "CA", Ancestry: types.AncestryGroupAll, Frequency: 0.3},
+		{ID: 4, Reference: "AC", Alternate: "GT", Ancestry: types.AncestryGroupAll, Frequency: 0.4},
+	}))
+
+	t.Run("GRCh38", func(t *testing.T) {
+		allele, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr1, 10001, "G", "A", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), allele.ID)
+
+		_, err = db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr1, 10001, "G", "T", types.AncestryGroupAll)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Liftover", func(t *testing.T) {
+		allele, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh37, types.Chr1, 10001, "G", "A", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), allele.ID)
+	})
+
+	t.Run("ReverseStrand", func(t *testing.T) {
+		allele, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh37, types.Chr3, 5001, "C", "T", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), allele.ID)
+
+		// The first base of an MNV on the reverse strand is the last base of the lifted alleles.
+		allele, err = db.GetAlleleByPosition(ctx, types.ReferenceGRCh37, types.Chr3, 5001, "CA", "TG", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), allele.ID)
+
+		_, err = db.GetAlleleByPosition(ctx, types.ReferenceGRCh37, types.Chr3, 5001, "CA", "C", types.AncestryGroupAll)
+		assert.Error(t, err)
+	})
+
+	t.Run("AlignmentGap", func(t *testing.T) {
+		// The first base maps, but the second is in a gap between alignment blocks.
+		_, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh37, types.Chr2, 1100, "AC", "GT", types.AncestryGroupAll)
+		assert.ErrorIs(t, err, genobase.ErrMinMatch)
+
+		allele, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh37, types.Chr2, 1099, "AC", "GT", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), allele.ID)
+	})
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	ctx := context.Background()
+
+	// A database from before the sequence table was added.
+	path := filepath.Join(t.TempDir(), "genobase.db")
+
+	old, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+
+	_, err = old.ExecContext(ctx, "CREATE TABLE variant (id INTEGER PRIMARY KEY, chromosome TEXT, position INTEGER, class TEXT)")
+	require.NoError(t, err)
+	require.NoError(t, old.Close())
+
+	// Read-only databases aren't migrated.
+	db, err := genobase.Open(ctx, slogt.New(t), path, genobase.ReadOnly)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+}
@@ -0,0 +1,332 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zymatik-com/genobase/types"
+	"github.com/zymatik-com/genobase/vcf"
+)
+
+// The gnomAD INFO field suffixes for each ancestry group, eg. "AF_afr".
+var gnomADAncestryGroups = []struct {
+	suffix   string
+	ancestry types.AncestryGroup
+}{
+	{"", types.AncestryGroupAll},
+	{"_afr", types.AncestryGroupAfrican},
+	{"_ami", types.AncestryGroupAmish},
+	{"_amr", types.AncestryGroupAmerican},
+	{"_asj", types.AncestryGroupAshkenazi},
+	{"_eas", types.AncestryGroupEastAsian},
+	{"_fin", types.AncestryGroupFinnish},
+	{"_mid", types.AncestryGroupMiddleEastern},
+	{"_nfe", types.AncestryGroupEuropean},
+	{"_sas", types.AncestryGroupSouthAsian},
+	{"_oth", types.AncestryGroupOther},
+	// gnomAD v4 renamed "oth" to "remaining".
+	{"_remaining", types.AncestryGroupOther},
+}
+
+// ImportGnomAD imports the per-ancestry allele frequencies (and allele counts,
+// numbers, homozygote and hemizygote counts, where present) from a gnomAD sites
+// VCF file (eg. gnomad.genomes.v3.1.2.sites.chr1.vcf.bgz). The file may be gzip
+// compressed. Only GRCh38 files are supported. Sites that did not pass all
+// filters, or that are on alternate contigs etc. are skipped. Sites without an
+// RSID are stored, along with a variant, using a coordinate derived ID (see
+// types.SyntheticVariantID).
+//
+// If a sequence source has been set, variants are normalized before they are
+// stored. As alleles are looked up one at a time, the alternate alleles of
+// multiallelic sites are split and normalized separately (as with bcftools
+// norm -m-), so each may end up at a different position (and, for sites without
+// an RSID, a different variant).
+//
+// Variants that already have alleles stored are skipped, so an interrupted
+// import can be resumed by importing the same file again.
+func (db *DB) ImportGnomAD(ctx context.Context, r io.Reader, opts ...ImportOption) error {
+	options := newImportOptions(opts...)
+	if options.assembly != types.ReferenceGRCh38 {
+		return fmt.Errorf("unsupported reference genome assembly for gnomAD: %s", options.assembly)
+	}
+
+	vr, err := vcf.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("could not open vcf file: %w", err)
+	}
+
+	known, err := db.KnownAlleles(ctx)
+	if err != nil {
+		return err
+	}
+
+	var imported, skipped int
+	batch := make([]types.Allele, 0, options.batchSize)
+	var variants []types.Variant
+
+	flush := func() error {
+		if len(variants) > 0 {
+			if err := db.StoreVariants(ctx, variants); err != nil {
+				return err
+			}
+		}
+
+		if err := db.StoreAlleles(ctx, batch); err != nil {
+			return err
+		}
+
+		imported += len(batch)
+		batch = batch[:0]
+		variants = variants[:0]
+
+		db.logger.Info("Importing alleles", "imported", imported, "skipped", skipped)
+
+		return nil
+	}
+
+	// The ID of the last record added to the batch.
+	var lastID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := vr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return fmt.Errorf("could not read allele: %w", err)
+		}
+
+		if !passedFilters(record) || len(record.Alternate) == 0 {
+			skipped++
+			continue
+		}
+
+		var rsids []int64
+		for _, id := range record.IDs {
+			if rsid, ok := parseRSID(id); ok {
+				rsids = append(rsids, rsid)
+			}
+		}
+
+		chromosome, err := types.ParseChromosome(record.Chromosome, types.ReferenceGRCh38)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		alleles, err := gnomADAlleles(record)
+		if err != nil {
+			return fmt.Errorf("could not parse alleles at %s:%d: %w", record.Chromosome, record.Position, err)
+		}
+
+		var recordAlleles []types.Allele
+		var recordVariants []types.Variant
+		for _, alternate := range record.Alternate {
+			position, reference, normalized, err := db.normalizeVariant(ctx, types.ReferenceGRCh38, chromosome, record.Position, record.Reference, alternate)
+			if err != nil {
+				return fmt.Errorf("could not normalize variant at %s:%d: %w", record.Chromosome, record.Position, err)
+			}
+
+			ids := rsids
+			if len(rsids) == 0 {
+				id, err := types.SyntheticVariantID(chromosome, position)
+				if err != nil {
+					return fmt.Errorf("could not generate variant id for %s:%d: %w", record.Chromosome, position, err)
+				}
+
+				ids = []int64{id}
+				if !known[id] && !slices.ContainsFunc(recordVariants, func(variant types.Variant) bool { return variant.ID == id }) {
+					recordVariants = append(recordVariants, types.Variant{
+						ID:         id,
+						Chromosome: chromosome,
+						Position:   position,
+						Class:      types.ClassifyVariant(reference, normalized[0]),
+					})
+				}
+			}
+
+			for _, id := range ids {
+				if known[id] {
+					continue
+				}
+
+				for _, allele := range alleles {
+					if allele.Alternate != alternate {
+						continue
+					}
+
+					allele.ID = id
+					allele.Reference = reference
+					allele.Alternate = normalized[0]
+					recordAlleles = append(recordAlleles, allele)
+				}
+			}
+		}
+
+		if len(recordAlleles) == 0 {
+			skipped++
+			continue
+		}
+
+		// Multiallelic sites can be split over multiple records, make sure all
+		// the alleles of a variant are stored together (so that resuming an
+		// import doesn't leave a variant with missing alleles).
+		if len(batch) >= options.batchSize && recordAlleles[0].ID != lastID {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		variants = append(variants, recordVariants...)
+		batch = append(batch, recordAlleles...)
+
+		lastID = recordAlleles[len(recordAlleles)-1].ID
+	}
+
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	db.logger.Info("Imported alleles", "imported", imported, "skipped", skipped)
+
+	return nil
+}
+
+// passedFilters returns true if the record has passed all filters (or no
+// filters have been applied).
+func passedFilters(record *vcf.Record) bool {
+	return len(record.Filter) == 0 || (len(record.Filter) == 1 && record.Filter[0] == "PASS")
+}
+
+// gnomADAlleles returns the alleles of a gnomAD record for each ancestry
+// group with a known allele frequency (and counts, if present).
+func gnomADAlleles(record *vcf.Record) ([]types.Allele, error) {
+	var alleles []types.Allele
+	for _, group := range gnomADAncestryGroups {
+		frequencies, err := perAlleleValues(record, "AF"+group.suffix)
+		if err != nil {
+			return nil, err
+		}
+
+		counts, err := perAlleleValues(record, "AC"+group.suffix)
+		if err != nil {
+			return nil, err
+		}
+
+		homozygotes, err := perAlleleValues(record, "nhomalt"+group.suffix)
+		if err != nil {
+			return nil, err
+		}
+
+		hemizygotes, err := perAlleleValues(record, "nhemi"+group.suffix)
+		if err != nil {
+			return nil, err
+		}
+
+		alleleNumber, _ := record.Info("AN" + group.suffix)
+
+		for i, alternate := range record.Alternate {
+			allele := types.Allele{
+				Reference: record.Reference,
+				Alternate: alternate,
+				Ancestry:  group.ancestry,
+			}
+
+			allele.AlleleNumber, err = parseCount(alleleNumber)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse AN%s: %w", group.suffix, err)
+			}
+
+			if counts != nil {
+				allele.AlleleCount, err = parseCount(counts[i])
+				if err != nil {
+					return nil, fmt.Errorf("could not parse AC%s: %w", group.suffix, err)
+				}
+			}
+
+			if homozygotes != nil {
+				allele.HomozygoteCount, err = parseCount(homozygotes[i])
+				if err != nil {
+					return nil, fmt.Errorf("could not parse nhomalt%s: %w", group.suffix, err)
+				}
+			}
+
+			if hemizygotes != nil {
+				allele.HemizygoteCount, err = parseCount(hemizygotes[i])
+				if err != nil {
+					return nil, fmt.Errorf("could not parse nhemi%s: %w", group.suffix, err)
+				}
+			}
+
+			switch {
+			case frequencies != nil && frequencies[i] != ".":
+				allele.Frequency, err = strconv.ParseFloat(frequencies[i], 64)
+				if err != nil {
+					return nil, fmt.Errorf("could not parse AF%s: %w", group.suffix, err)
+				}
+			// The frequency will be computed from the counts.
+			case allele.AlleleCount != nil && allele.AlleleNumber != nil && *allele.AlleleNumber > 0:
+			default:
+				// Missing, eg. no individuals from the ancestry group were called.
+				continue
+			}
+
+			alleles = append(alleles, allele)
+		}
+	}
+
+	return alleles, nil
+}
+
+// perAlleleValues returns the values of an INFO field that has one value per
+// alternate allele (Number=A), or nil if the field is not present.
+func perAlleleValues(record *vcf.Record, key string) ([]string, error) {
+	value, ok := record.Info(key)
+	if !ok {
+		return nil, nil
+	}
+
+	values := strings.Split(value, ",")
+	if len(values) != len(record.Alternate) {
+		return nil, fmt.Errorf("expected %d values for %s, got %d", len(record.Alternate), key, len(values))
+	}
+
+	return values, nil
+}
+
+// parseCount parses an optional count, returning nil if it is missing.
+func parseCount(value string) (*int64, error) {
+	if value == "" || value == "." {
+		return nil, nil
+	}
+
+	count, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &count, nil
+}
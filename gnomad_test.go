@@ -0,0 +1,157 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestImportGnomAD(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	// Pretend a previous import was interrupted after storing rs1.
+	require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+		{ID: 1, Reference: "C", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.5},
+	}))
+
+	// So that indels are normalized.
+	require.NoError(t, db.ImportSequence(ctx, types.ReferenceGRCh38, strings.NewReader(">chr1\nGGGGTCACACAGGGGG\n")))
+
+	f, err := os.Open("vcf/testdata/gnomad.vcf")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+
+	// Only GRCh38 is supported.
+	require.Error(t, db.ImportGnomAD(ctx, f, genobase.Assembly(types.ReferenceGRCh37)))
+
+	require.NoError(t, db.ImportGnomAD(ctx, f, genobase.BatchSize(1)))
+
+	allele, err := db.GetAllele(ctx, 334, "T", "A", types.AncestryGroupAll)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.00348240, allele.Frequency, 1e-8)
+
+	require.NotNil(t, allele.AlleleCount)
+	assert.Equal(t, int64(530), *allele.AlleleCount)
+	require.NotNil(t, allele.AlleleNumber)
+	assert.Equal(t, int64(152194), *allele.AlleleNumber)
+	require.NotNil(t, allele.HomozygoteCount)
+	assert.Equal(t, int64(3), *allele.HomozygoteCount)
+	assert.Nil(t, allele.HemizygoteCount)
+
+	allele, err = db.GetAllele(ctx, 334, "T", "A", types.AncestryGroupAfrican)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0121387, allele.Frequency)
+	assert.Nil(t, allele.AlleleCount)
+
+	allele, err = db.GetAllele(ctx, 334, "T", "A", types.AncestryGroupOther)
+	require.NoError(t, err)
+	assert.Equal(t, 0.00191205, allele.Frequency)
+
+	// Hemizygote counts (outside of the pseudoautosomal regions of chromosome X).
+	allele, err = db.GetAllele(ctx, 3, "C", "T", types.AncestryGroupAll)
+	require.NoError(t, err)
+	require.NotNil(t, allele.HomozygoteCount)
+	assert.Equal(t, int64(10), *allele.HomozygoteCount)
+	require.NotNil(t, allele.HemizygoteCount)
+	assert.Equal(t, int64(50), *allele.HemizygoteCount)
+
+	allele, err = db.GetAllele(ctx, 3, "C", "T", types.AncestryGroupAfrican)
+	require.NoError(t, err)
+	assert.InDelta(t, 20.0/30000, allele.Frequency, 1e-9)
+	require.NotNil(t, allele.HemizygoteCount)
+	assert.Equal(t, int64(8), *allele.HemizygoteCount)
+
+	// Missing frequency.
+	_, err = db.GetAllele(ctx, 334, "T", "A", types.AncestryGroupAshkenazi)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	alleles, err := db.GetAlleles(ctx, 334)
+	require.NoError(t, err)
+	assert.Len(t, alleles, 2)
+
+	// Filtered.
+	_, err = db.GetAllele(ctx, 4680, "G", "A", types.AncestryGroupAll)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Multiallelic site with multiple RSIDs.
+	allele, err = db.GetAllele(ctx, 2, "C", "G", types.AncestryGroupAll)
+	require.NoError(t, err)
+	assert.Equal(t, 0.2, allele.Frequency)
+
+	allele, err = db.GetAllele(ctx, 2, "C", "T", types.AncestryGroupEuropean)
+	require.NoError(t, err)
+	assert.Equal(t, 0.15, allele.Frequency)
+
+	_, err = db.GetAllele(ctx, 2, "C", "G", types.AncestryGroupEuropean)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// No RSID.
+	allele, err = db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr22, 19963749, "C", "T", types.AncestryGroupAll)
+	require.NoError(t, err)
+	assert.Less(t, allele.ID, int64(0))
+	assert.InDelta(t, 2.0/152000, allele.Frequency, 1e-9)
+
+	variant, err := db.GetVariant(ctx, allele.ID)
+	require.NoError(t, err)
+	assert.False(t, variant.HasRSID())
+	assert.Equal(t, types.VariantClassSNV, variant.Class)
+
+	// The alternate alleles of multiallelic sites are normalized separately.
+	deletion, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr1, 5, "TCA", "T", types.AncestryGroupAll)
+	require.NoError(t, err)
+	assert.Equal(t, 0.003, deletion.Frequency)
+
+	snv, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr1, 9, "A", "G", types.AncestryGroupAll)
+	require.NoError(t, err)
+	assert.Equal(t, 0.004, snv.Frequency)
+	assert.NotEqual(t, deletion.ID, snv.ID)
+
+	variant, err = db.GetVariant(ctx, snv.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), variant.Position)
+	assert.Equal(t, types.VariantClassSNV, variant.Class)
+
+	// Without a variant, alleles can't be looked up by position.
+	_, err = db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr11, 5227002, "T", "A", types.AncestryGroupAll)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+		{ID: 334, Chromosome: types.Chr11, Position: 5227002, Class: types.VariantClassSNV},
+	}))
+
+	allele, err = db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr11, 5227002, "T", "A", types.AncestryGroupAll)
+	require.NoError(t, err)
+	assert.Equal(t, int64(334), allele.ID)
+
+	// Already imported, so not overwritten.
+	allele, err = db.GetAllele(ctx, 1, "C", "T", types.AncestryGroupAll)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, allele.Frequency)
+}
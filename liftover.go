@@ -0,0 +1,559 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/zymatik-com/genobase/types"
+)
+
+var (
+	// ErrUnmapped is returned when a position is not covered by any liftover chain.
+	ErrUnmapped = errors.New("position is not covered by any chain")
+	// ErrInGap is returned when a position falls within a gap between aligned
+	// blocks, that is, a region that is unaligned in both assemblies.
+	ErrInGap = errors.New("position falls in an alignment gap")
+	// ErrDeleted is returned when a position has no counterpart in the target
+	// assembly (the bases have been deleted).
+	ErrDeleted = errors.New("position is deleted in the target assembly")
+	// ErrNoLiftoverPath is returned when there are no chains (direct or through
+	// intermediate assemblies) between two assemblies.
+	ErrNoLiftoverPath = errors.New("no liftover path between assemblies")
+	// ErrMinMatch is returned when too few bases of a region could be mapped
+	// to the target assembly.
+	ErrMinMatch = errors.New("region does not meet the minimum match ratio")
+)
+
+// DefaultMinMatch is the default minimum ratio of bases that must be mapped
+// for a region to be lifted over (the same as UCSC liftOver).
+const DefaultMinMatch = 0.95
+
+type liftoverOptions struct {
+	minMatch float64
+	split    bool
+}
+
+// LiftoverOption configures region liftover.
+type LiftoverOption func(*liftoverOptions)
+
+// MinMatch sets the minimum ratio of bases in a region that must be mapped
+// for it to be lifted over (equivalent to liftOver -minMatch).
+func MinMatch(ratio float64) LiftoverOption {
+	return func(opts *liftoverOptions) {
+		opts.minMatch = ratio
+	}
+}
+
+// Split splits a lifted region into multiple pieces where it spans gaps in
+// the target assembly, rather than returning a single region spanning the
+// first to the last mapped base.
+func Split(opts *liftoverOptions) {
+	opts.split = true
+}
+
+// Liftover maps a (1-based) position on a chromosome in one reference genome
+// assembly to the equivalent position in another assembly.
+// If the position cannot be mapped, an error wrapping ErrUnmapped, ErrInGap,
+// or ErrDeleted is returned.
+func (db *DB) Liftover(ctx context.Context, from, to types.Reference, chromosome types.Chromosome, position int64) (*types.LiftedPosition, error) {
+	if from == to {
+		return unliftedPosition(to, chromosome, position), nil
+	}
+
+	// Chains use 0-based coordinates.
+	chains, err := db.getChains(ctx, from, to, chromosome, position-1, position)
+	if err != nil {
+		return nil, err
+	}
+
+	return liftPosition(to, chromosome, position, chains, func(chain *types.Chain, refOffset int64) ([]types.Alignment, error) {
+		return db.getAlignmentsAround(ctx, chain.ID, refOffset)
+	})
+}
+
+// LiftoverMultiHop lifts a (1-based) position over from one reference genome
+// assembly to another, through intermediate assemblies if there are no chains
+// directly between them (see FindLiftoverPath). The path taken is returned
+// along with the lifted position. If any hop fails, a *HopError is returned.
+func (db *DB) LiftoverMultiHop(ctx context.Context, from, to types.Reference, chromosome types.Chromosome, position int64) (*types.LiftedPosition, []types.Reference, error) {
+	path, err := db.FindLiftoverPath(ctx, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lifted, err := db.LiftoverPath(ctx, path, chromosome, position)
+	if err != nil {
+		return nil, path, err
+	}
+
+	return lifted, path, nil
+}
+
+// FindLiftoverPath returns the shortest sequence of reference genome assemblies,
+// starting with from and ending with to, for which chains are stored between
+// each consecutive pair. If there is no such path, an error wrapping
+// ErrNoLiftoverPath is returned.
+func (db *DB) FindLiftoverPath(ctx context.Context, from, to types.Reference) ([]types.Reference, error) {
+	rows, err := db.db.QueryxContext(ctx, "SELECT DISTINCT ref, query_ref FROM liftover_chain ORDER BY ref, query_ref")
+	if err != nil {
+		return nil, fmt.Errorf("could not query chains: %w", err)
+	}
+	defer rows.Close()
+
+	edges := make(map[types.Reference][]types.Reference)
+	for rows.Next() {
+		var ref, queryRef types.Reference
+		if err := rows.Scan(&ref, &queryRef); err != nil {
+			return nil, fmt.Errorf("could not scan chain: %w", err)
+		}
+
+		edges[ref] = append(edges[ref], queryRef)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan chains: %w", err)
+	}
+
+	// Breadth first search, so that we find the path with the fewest hops.
+	previous := map[types.Reference]types.Reference{from: ""}
+	queue := []types.Reference{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current == to {
+			var path []types.Reference
+			for ref := to; ref != ""; ref = previous[ref] {
+				path = append([]types.Reference{ref}, path...)
+			}
+
+			return path, nil
+		}
+
+		for _, next := range edges[current] {
+			if _, ok := previous[next]; !ok {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("could not find path from %s to %s: %w", from, to, ErrNoLiftoverPath)
+}
+
+// LiftoverPath lifts a (1-based) position over through a sequence of reference
+// genome assemblies, eg. NCBI36 → GRCh37 → GRCh38, for when there are no chains
+// directly between the first and last assemblies. If any hop fails, a *HopError
+// is returned.
+func (db *DB) LiftoverPath(ctx context.Context, path []types.Reference, chromosome types.Chromosome, position int64) (*types.LiftedPosition, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("liftover path must contain at least one assembly")
+	}
+
+	lifted := unliftedPosition(path[0], chromosome, position)
+	for i := 1; i < len(path); i++ {
+		hop, err := db.Liftover(ctx, path[i-1], path[i], lifted.Chromosome, lifted.Position)
+		if err != nil {
+			return nil, &HopError{From: path[i-1], To: path[i], Err: err}
+		}
+
+		// Mapping onto the reverse strand twice puts us back on the forward strand.
+		if lifted.Strand == hop.Strand {
+			hop.Strand = "+"
+		} else {
+			hop.Strand = "-"
+		}
+
+		lifted = hop
+	}
+
+	return lifted, nil
+}
+
+// HopError is returned when a position cannot be lifted over one of the hops
+// in a multi-hop liftover.
+type HopError struct {
+	From types.Reference // The source assembly of the failed hop.
+	To   types.Reference // The target assembly of the failed hop.
+	Err  error           // Why the hop failed.
+}
+
+func (e *HopError) Error() string {
+	return fmt.Sprintf("liftover from %s to %s failed: %v", e.From, e.To, e.Err)
+}
+
+func (e *HopError) Unwrap() error {
+	return e.Err
+}
+
+// LiftoverRegion maps a region on a chromosome in one reference genome assembly
+// to the equivalent region(s) in another assembly. The region is mapped using
+// the highest scoring chain for which the ratio of mapped bases meets the
+// minimum match ratio (DefaultMinMatch unless otherwise specified).
+// If the region cannot be mapped, an error wrapping ErrUnmapped or ErrMinMatch
+// is returned.
+func (db *DB) LiftoverRegion(ctx context.Context, from, to types.Reference, region types.Region, opts ...LiftoverOption) ([]types.LiftedRegion, error) {
+	if from == to {
+		return unliftedRegion(to, region), nil
+	}
+
+	chains, err := db.getChains(ctx, from, to, region.Chromosome, region.Start, region.End)
+	if err != nil {
+		return nil, err
+	}
+
+	return liftRegion(to, region, chains, func(chain *types.Chain, startOffset, endOffset int64) ([]types.Alignment, error) {
+		return db.getAlignmentsBetween(ctx, chain.ID, startOffset, endOffset)
+	}, opts...)
+}
+
+// alignmentsAroundFunc returns the alignment block in a chain at or immediately
+// before the offset, and the block immediately after it (additional blocks are allowed).
+type alignmentsAroundFunc func(chain *types.Chain, refOffset int64) ([]types.Alignment, error)
+
+// alignmentsBetweenFunc returns the alignment blocks in a chain that overlap the
+// interval [startOffset, endOffset) (additional blocks are allowed).
+type alignmentsBetweenFunc func(chain *types.Chain, startOffset, endOffset int64) ([]types.Alignment, error)
+
+// liftPosition maps a position using the first of the candidate chains (ordered
+// by descending score) that has it in an aligned block.
+func liftPosition(to types.Reference, chromosome types.Chromosome, position int64, chains []types.Chain, alignmentsAround alignmentsAroundFunc) (*types.LiftedPosition, error) {
+	if len(chains) == 0 {
+		return nil, fmt.Errorf("could not liftover %s:%d: %w", chromosome, position, ErrUnmapped)
+	}
+
+	// Chains are ordered by score, so the first error is the most relevant.
+	var firstErr error
+	for i := range chains {
+		chain := &chains[i]
+		offset := position - 1 - chain.RefStart
+
+		alignments, err := alignmentsAround(chain, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		queryOffset, err := liftOffset(alignments, offset)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		queryPosition, strand := liftedPosition(chain, queryOffset)
+
+		return &types.LiftedPosition{
+			Reference:  to,
+			Chromosome: chain.QueryName,
+			Position:   queryPosition,
+			Strand:     strand,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("could not liftover %s:%d: %w", chromosome, position, firstErr)
+}
+
+// liftRegion maps a region using the first of the candidate chains (ordered by
+// descending score) that meets the minimum match ratio.
+func liftRegion(to types.Reference, region types.Region, chains []types.Chain, alignmentsBetween alignmentsBetweenFunc, opts ...LiftoverOption) ([]types.LiftedRegion, error) {
+	options := liftoverOptions{
+		minMatch: DefaultMinMatch,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if region.Length() <= 0 {
+		return nil, fmt.Errorf("invalid region %s:%d-%d", region.Chromosome, region.Start, region.End)
+	}
+
+	if len(chains) == 0 {
+		return nil, fmt.Errorf("could not liftover %s:%d-%d: %w", region.Chromosome, region.Start, region.End, ErrUnmapped)
+	}
+
+	for i := range chains {
+		chain := &chains[i]
+
+		alignments, err := alignmentsBetween(chain, region.Start-chain.RefStart, region.End-chain.RefStart)
+		if err != nil {
+			return nil, err
+		}
+
+		lifted := liftRegionWithChain(chain, alignments, to, region, &options)
+		if len(lifted) > 0 {
+			return lifted, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not liftover %s:%d-%d: %w", region.Chromosome, region.Start, region.End, ErrMinMatch)
+}
+
+// unliftedPosition returns a position as is, for when the source and target
+// assemblies are the same.
+func unliftedPosition(to types.Reference, chromosome types.Chromosome, position int64) *types.LiftedPosition {
+	return &types.LiftedPosition{
+		Reference:  to,
+		Chromosome: chromosome,
+		Position:   position,
+		Strand:     "+",
+	}
+}
+
+// unliftedRegion returns a region as is, for when the source and target
+// assemblies are the same.
+func unliftedRegion(to types.Reference, region types.Region) []types.LiftedRegion {
+	return []types.LiftedRegion{{
+		Reference:   to,
+		Chromosome:  region.Chromosome,
+		Start:       region.Start,
+		End:         region.End,
+		Strand:      "+",
+		Source:      region,
+		MappedBases: region.Length(),
+		Coverage:    1,
+	}}
+}
+
+// liftRegionWithChain maps a region using a single chain, returning nothing if
+// the ratio of mapped bases is below the minimum match ratio.
+func liftRegionWithChain(chain *types.Chain, alignments []types.Alignment, to types.Reference, region types.Region, options *liftoverOptions) []types.LiftedRegion {
+	segments := alignedSegments(chain, alignments, region.Start, region.End)
+
+	var mapped int64
+	for _, s := range segments {
+		mapped += s.refEnd - s.refStart
+	}
+
+	if mapped == 0 || float64(mapped)/float64(region.Length()) < options.minMatch {
+		return nil
+	}
+
+	if !options.split {
+		first, last := segments[0], segments[len(segments)-1]
+		segments = []segment{{
+			refStart:   first.refStart,
+			refEnd:     last.refEnd,
+			queryStart: first.queryStart,
+			queryEnd:   last.queryEnd,
+			mapped:     mapped,
+		}}
+	}
+
+	lifted := make([]types.LiftedRegion, 0, len(segments))
+	for _, s := range segments {
+		start, end := s.queryStart, s.queryEnd
+		strand := "+"
+		if chain.QueryStrand == "-" {
+			start, end = chain.QuerySize-s.queryEnd, chain.QuerySize-s.queryStart
+			strand = "-"
+		}
+
+		lifted = append(lifted, types.LiftedRegion{
+			Reference:  to,
+			Chromosome: chain.QueryName,
+			Start:      start,
+			End:        end,
+			Strand:     strand,
+			Source: types.Region{
+				Chromosome: region.Chromosome,
+				Start:      s.refStart,
+				End:        s.refEnd,
+			},
+			MappedBases: s.mapped,
+			Coverage:    float64(s.mapped) / float64(region.Length()),
+		})
+	}
+
+	return lifted
+}
+
+// segment is a run of aligned bases, in 0-based, half-open coordinates. Query
+// coordinates are relative to the chain's query strand.
+type segment struct {
+	refStart, refEnd     int64
+	queryStart, queryEnd int64
+	// The number of aligned bases within the segment.
+	mapped int64
+}
+
+// alignedSegments returns the portions of the interval [start, end) that are
+// aligned by the chain. Consecutive blocks that are contiguous in the target
+// assembly (ie. deletions in the target) are merged into a single segment.
+// The alignments must be sorted by their reference offset.
+func alignedSegments(chain *types.Chain, alignments []types.Alignment, start, end int64) []segment {
+	var segments []segment
+	for _, alignment := range alignments {
+		blockStart := chain.RefStart + alignment.RefOffset
+		blockEnd := blockStart + alignment.Size
+
+		refStart, refEnd := max(start, blockStart), min(end, blockEnd)
+		if refStart >= refEnd {
+			continue
+		}
+
+		queryStart := chain.QueryStart + alignment.QueryOffset + (refStart - blockStart)
+		queryEnd := queryStart + (refEnd - refStart)
+
+		if n := len(segments); n > 0 && segments[n-1].queryEnd == queryStart {
+			segments[n-1].refEnd = refEnd
+			segments[n-1].queryEnd = queryEnd
+			segments[n-1].mapped += refEnd - refStart
+			continue
+		}
+
+		segments = append(segments, segment{
+			refStart:   refStart,
+			refEnd:     refEnd,
+			queryStart: queryStart,
+			queryEnd:   queryEnd,
+			mapped:     refEnd - refStart,
+		})
+	}
+
+	return segments
+}
+
+// liftedPosition converts an offset from the start of a chain in the target
+// assembly to a (1-based) position on the forward strand, and the strand
+// the position maps to.
+func liftedPosition(chain *types.Chain, queryOffset int64) (int64, string) {
+	position := chain.QueryStart + queryOffset
+
+	// Coordinates on the reverse strand are counted from the end of the chromosome.
+	if chain.QueryStrand == "-" {
+		return chain.QuerySize - position, "-"
+	}
+
+	return position + 1, "+"
+}
+
+// getChains returns all the chains that overlap the 0-based, half-open interval
+// [start, end) on the chromosome, ordered by descending score.
+func (db *DB) getChains(ctx context.Context, from, to types.Reference, chromosome types.Chromosome, start, end int64) ([]types.Chain, error) {
+	rows, err := db.db.QueryxContext(ctx, `SELECT * FROM liftover_chain
+		WHERE ref = ? AND query_ref = ? AND ref_name = ? AND ref_start < ? AND ref_end > ?
+		ORDER BY score DESC`, from, to, chromosome, end, start)
+	if err != nil {
+		return nil, fmt.Errorf("could not query chains: %w", err)
+	}
+	defer rows.Close()
+
+	var chains []types.Chain
+	for rows.Next() {
+		var chain types.Chain
+		if err := rows.StructScan(&chain); err != nil {
+			return nil, fmt.Errorf("could not unmarshal chain: %w", err)
+		}
+
+		chains = append(chains, chain)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan chains: %w", err)
+	}
+
+	return chains, nil
+}
+
+// getAlignmentsAround returns the alignment block at or immediately before the
+// offset, and the block immediately after it (if any).
+func (db *DB) getAlignmentsAround(ctx context.Context, chainID, refOffset int64) ([]types.Alignment, error) {
+	rows, err := db.db.QueryxContext(ctx, `SELECT * FROM (
+			SELECT * FROM liftover_alignment WHERE chain_id = ? AND ref_offset <= ? ORDER BY ref_offset DESC LIMIT 1
+		) UNION ALL SELECT * FROM (
+			SELECT * FROM liftover_alignment WHERE chain_id = ? AND ref_offset > ? ORDER BY ref_offset ASC LIMIT 1
+		) ORDER BY ref_offset ASC`, chainID, refOffset, chainID, refOffset)
+	if err != nil {
+		return nil, fmt.Errorf("could not query alignments: %w", err)
+	}
+	defer rows.Close()
+
+	var alignments []types.Alignment
+	for rows.Next() {
+		var alignment types.Alignment
+		if err := rows.StructScan(&alignment); err != nil {
+			return nil, fmt.Errorf("could not unmarshal alignment: %w", err)
+		}
+
+		alignments = append(alignments, alignment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan alignments: %w", err)
+	}
+
+	return alignments, nil
+}
+
+// getAlignmentsBetween returns all the alignment blocks in the chain that
+// overlap the interval [startOffset, endOffset), ordered by reference offset.
+func (db *DB) getAlignmentsBetween(ctx context.Context, chainID, startOffset, endOffset int64) ([]types.Alignment, error) {
+	rows, err := db.db.QueryxContext(ctx, `SELECT * FROM liftover_alignment
+		WHERE chain_id = ? AND ref_offset < ? AND ref_offset + size > ?
+		ORDER BY ref_offset ASC`, chainID, endOffset, startOffset)
+	if err != nil {
+		return nil, fmt.Errorf("could not query alignments: %w", err)
+	}
+	defer rows.Close()
+
+	var alignments []types.Alignment
+	for rows.Next() {
+		var alignment types.Alignment
+		if err := rows.StructScan(&alignment); err != nil {
+			return nil, fmt.Errorf("could not unmarshal alignment: %w", err)
+		}
+
+		alignments = append(alignments, alignment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan alignments: %w", err)
+	}
+
+	return alignments, nil
+}
+
+// liftOffset maps an offset from the start of a chain in the source assembly
+// to the equivalent offset from the start of the chain in the target assembly.
+// The alignments must be sorted by their reference offset.
+func liftOffset(alignments []types.Alignment, refOffset int64) (int64, error) {
+	i := sort.Search(len(alignments), func(i int) bool {
+		return alignments[i].RefOffset > refOffset
+	}) - 1
+	if i < 0 {
+		return -1, ErrUnmapped
+	}
+
+	block := alignments[i]
+	if refOffset < block.RefOffset+block.Size {
+		return block.QueryOffset + (refOffset - block.RefOffset), nil
+	}
+
+	if i+1 == len(alignments) {
+		return -1, ErrUnmapped
+	}
+
+	// If the gap is only present in the source assembly, the bases have been
+	// deleted in the target assembly.
+	if alignments[i+1].QueryOffset == block.QueryOffset+block.Size {
+		return -1, ErrDeleted
+	}
+
+	return -1, ErrInGap
+}
@@ -0,0 +1,196 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/zymatik-com/genobase/types"
+)
+
+// LiftoverIndex is an in-memory index of the liftover chains between two
+// reference genome assemblies. It is safe for concurrent use and lifts over
+// positions without querying the database.
+type LiftoverIndex struct {
+	from, to    types.Reference
+	chromosomes map[types.Chromosome]*chromosomeChains
+	alignments  map[int64][]types.Alignment
+}
+
+// chromosomeChains are the chains for a single chromosome in the source assembly.
+type chromosomeChains struct {
+	// Chains sorted by their start position.
+	chains []types.Chain
+	// The maximum end position of chains[:i+1], used to bound overlap searches.
+	maxEnd []int64
+}
+
+// LiftoverResult is the outcome of lifting over a single position in a batch.
+type LiftoverResult struct {
+	Lifted *types.LiftedPosition // The lifted position, if successful.
+	Err    error                 // Why the position could not be lifted over.
+}
+
+// LoadLiftoverIndex loads all the chains (and their alignment blocks) for
+// lifting over from one reference genome assembly to another into memory.
+func (db *DB) LoadLiftoverIndex(ctx context.Context, from, to types.Reference) (*LiftoverIndex, error) {
+	idx := &LiftoverIndex{
+		from:        from,
+		to:          to,
+		chromosomes: make(map[types.Chromosome]*chromosomeChains),
+		alignments:  make(map[int64][]types.Alignment),
+	}
+
+	if from == to {
+		return idx, nil
+	}
+
+	rows, err := db.db.QueryxContext(ctx, "SELECT * FROM liftover_chain WHERE ref = ? AND query_ref = ? ORDER BY ref_name, ref_start", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not query chains: %w", err)
+	}
+	defer rows.Close()
+
+	var nChains int
+	for rows.Next() {
+		var chain types.Chain
+		if err := rows.StructScan(&chain); err != nil {
+			return nil, fmt.Errorf("could not unmarshal chain: %w", err)
+		}
+
+		c, ok := idx.chromosomes[chain.RefName]
+		if !ok {
+			c = &chromosomeChains{}
+			idx.chromosomes[chain.RefName] = c
+		}
+
+		maxEnd := chain.RefEnd
+		if n := len(c.maxEnd); n > 0 {
+			maxEnd = max(maxEnd, c.maxEnd[n-1])
+		}
+
+		c.chains = append(c.chains, chain)
+		c.maxEnd = append(c.maxEnd, maxEnd)
+		nChains++
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan chains: %w", err)
+	}
+
+	rows, err = db.db.QueryxContext(ctx, `SELECT a.* FROM liftover_alignment a
+		INNER JOIN liftover_chain c ON a.chain_id = c.id
+		WHERE c.ref = ? AND c.query_ref = ? ORDER BY a.chain_id, a.ref_offset`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not query alignments: %w", err)
+	}
+	defer rows.Close()
+
+	var nAlignments int
+	for rows.Next() {
+		var alignment types.Alignment
+		if err := rows.StructScan(&alignment); err != nil {
+			return nil, fmt.Errorf("could not unmarshal alignment: %w", err)
+		}
+
+		idx.alignments[alignment.ChainID] = append(idx.alignments[alignment.ChainID], alignment)
+		nAlignments++
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan alignments: %w", err)
+	}
+
+	db.logger.Debug("Loaded liftover index",
+		"from", from, "to", to, "chains", nChains, "alignments", nAlignments)
+
+	return idx, nil
+}
+
+// Liftover maps a (1-based) position on a chromosome to the equivalent position
+// in the target assembly. See DB.Liftover for details.
+func (idx *LiftoverIndex) Liftover(chromosome types.Chromosome, position int64) (*types.LiftedPosition, error) {
+	if idx.from == idx.to {
+		return unliftedPosition(idx.to, chromosome, position), nil
+	}
+
+	return liftPosition(idx.to, chromosome, position, idx.chains(chromosome, position-1, position), idx.chainAlignments)
+}
+
+// LiftoverRegion maps a region on a chromosome to the equivalent region(s) in
+// the target assembly. See DB.LiftoverRegion for details.
+func (idx *LiftoverIndex) LiftoverRegion(region types.Region, opts ...LiftoverOption) ([]types.LiftedRegion, error) {
+	if idx.from == idx.to {
+		return unliftedRegion(idx.to, region), nil
+	}
+
+	return liftRegion(idx.to, region, idx.chains(region.Chromosome, region.Start, region.End),
+		func(chain *types.Chain, startOffset, endOffset int64) ([]types.Alignment, error) {
+			alignments := idx.alignments[chain.ID]
+
+			first := sort.Search(len(alignments), func(i int) bool {
+				return alignments[i].RefOffset+alignments[i].Size > startOffset
+			})
+			last := sort.Search(len(alignments), func(i int) bool {
+				return alignments[i].RefOffset >= endOffset
+			})
+
+			return alignments[first:max(first, last)], nil
+		}, opts...)
+}
+
+// LiftoverBatch maps a batch of positions (in any order) to the equivalent
+// positions in the target assembly. The results are returned in the same order
+// as the positions.
+func (idx *LiftoverIndex) LiftoverBatch(positions []types.Locus) []LiftoverResult {
+	results := make([]LiftoverResult, len(positions))
+	for i, locus := range positions {
+		results[i].Lifted, results[i].Err = idx.Liftover(locus.Chromosome, locus.Position)
+	}
+
+	return results
+}
+
+// chains returns all the chains that overlap the 0-based, half-open interval
+// [start, end) on the chromosome, ordered by descending score.
+func (idx *LiftoverIndex) chains(chromosome types.Chromosome, start, end int64) []types.Chain {
+	c, ok := idx.chromosomes[chromosome]
+	if !ok {
+		return nil
+	}
+
+	// All the chains that start before the end of the interval.
+	n := sort.Search(len(c.chains), func(i int) bool {
+		return c.chains[i].RefStart >= end
+	})
+
+	var chains []types.Chain
+	for i := n - 1; i >= 0 && c.maxEnd[i] > start; i-- {
+		if c.chains[i].RefEnd > start {
+			chains = append(chains, c.chains[i])
+		}
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		return chains[i].Score > chains[j].Score
+	})
+
+	return chains
+}
+
+// chainAlignments returns all the alignment blocks for a chain.
+func (idx *LiftoverIndex) chainAlignments(chain *types.Chain, _ int64) ([]types.Alignment, error) {
+	return idx.alignments[chain.ID], nil
+}
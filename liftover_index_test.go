@@ -0,0 +1,70 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestLiftoverIndex(t *testing.T) {
+	ctx := context.Background()
+
+	db := openLiftoverDB(t)
+
+	idx, err := db.LoadLiftoverIndex(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38)
+	require.NoError(t, err)
+
+	// Unsorted, and covering mapped, unmapped, gap, and deleted positions.
+	positions := []types.Locus{
+		{Chromosome: types.Chr2, Position: 1151},
+		{Chromosome: types.Chr1, Position: 227480},
+		{Chromosome: types.Chr1, Position: 10001},
+		{Chromosome: types.Chr1, Position: 100},
+		{Chromosome: types.Chr3, Position: 5001},
+		{Chromosome: types.Chr1, Position: 217480},
+		{Chromosome: types.Chr2, Position: 1121},
+		{Chromosome: types.ChrX, Position: 1000},
+	}
+
+	results := idx.LiftoverBatch(positions)
+	require.Len(t, results, len(positions))
+
+	for i, locus := range positions {
+		expected, expectedErr := db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, locus.Chromosome, locus.Position)
+
+		assert.Equal(t, expected, results[i].Lifted, "%s:%d", locus.Chromosome, locus.Position)
+		assert.Equal(t, expectedErr, results[i].Err, "%s:%d", locus.Chromosome, locus.Position)
+	}
+
+	assert.ErrorIs(t, results[5].Err, genobase.ErrInGap)
+	assert.ErrorIs(t, results[6].Err, genobase.ErrDeleted)
+
+	for _, region := range []types.Region{
+		{Chromosome: types.Chr1, Start: 10000, End: 20000},
+		{Chromosome: types.Chr1, Start: 160000, End: 230000},
+		{Chromosome: types.Chr3, Start: 5000, End: 5010},
+	} {
+		expected, expectedErr := db.LiftoverRegion(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, region,
+			genobase.MinMatch(0.2), genobase.Split)
+
+		lifted, err := idx.LiftoverRegion(region, genobase.MinMatch(0.2), genobase.Split)
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, expected, lifted)
+	}
+}
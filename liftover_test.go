@@ -0,0 +1,333 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+// openLiftoverDB opens a database populated with some example chains.
+func openLiftoverDB(t *testing.T) *genobase.DB {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	chainID, err := db.StoreChain(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, &types.Chain{
+		Score:       2,
+		Ref:         types.ReferenceGRCh37,
+		RefName:     "1",
+		RefSize:     249250621,
+		RefStrand:   "+",
+		RefStart:    10000,
+		RefEnd:      267719,
+		QueryName:   "1",
+		QuerySize:   248956422,
+		QueryStrand: "+",
+		QueryStart:  10000,
+		QueryEnd:    297968,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.StoreAlignments(ctx, chainID, []types.Alignment{
+		{RefOffset: 0, QueryOffset: 0, Size: 167417},
+		{RefOffset: 217417, QueryOffset: 247666, Size: 40302},
+	}))
+
+	chainID, err = db.StoreChain(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, &types.Chain{
+		Score:       1,
+		Ref:         types.ReferenceGRCh37,
+		RefName:     "2",
+		RefSize:     243199373,
+		RefStrand:   "+",
+		RefStart:    1000,
+		RefEnd:      1250,
+		QueryName:   "2",
+		QuerySize:   242193529,
+		QueryStrand: "+",
+		QueryStart:  2000,
+		QueryEnd:    2200,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.StoreAlignments(ctx, chainID, []types.Alignment{
+		{RefOffset: 0, QueryOffset: 0, Size: 100},
+		{RefOffset: 150, QueryOffset: 100, Size: 100},
+	}))
+
+	chainID, err = db.StoreChain(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, &types.Chain{
+		Score:       1,
+		Ref:         types.ReferenceGRCh37,
+		RefName:     "3",
+		RefSize:     198022430,
+		RefStrand:   "+",
+		RefStart:    5000,
+		RefEnd:      5100,
+		QueryName:   "3",
+		QuerySize:   198295559,
+		QueryStrand: "-",
+		QueryStart:  198290000,
+		QueryEnd:    198290100,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.StoreAlignments(ctx, chainID, []types.Alignment{
+		{RefOffset: 0, QueryOffset: 0, Size: 100},
+	}))
+
+	return db
+}
+
+func TestLiftover(t *testing.T) {
+	ctx := context.Background()
+
+	db := openLiftoverDB(t)
+
+	t.Run("Mapped", func(t *testing.T) {
+		lifted, err := db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr1, 10001)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.ReferenceGRCh38, lifted.Reference)
+		assert.Equal(t, types.Chr1, lifted.Chromosome)
+		assert.Equal(t, int64(10001), lifted.Position)
+
+		lifted, err = db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr1, 227480)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.Chr1, lifted.Chromosome)
+		assert.Equal(t, int64(257729), lifted.Position)
+
+		lifted, err = db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr2, 1151)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.Chr2, lifted.Chromosome)
+		assert.Equal(t, int64(2101), lifted.Position)
+		assert.False(t, lifted.ReverseComplemented())
+	})
+
+	t.Run("ReverseStrand", func(t *testing.T) {
+		lifted, err := db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr3, 5001)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.Chr3, lifted.Chromosome)
+		assert.Equal(t, int64(5559), lifted.Position)
+		assert.Equal(t, "-", lifted.Strand)
+		assert.True(t, lifted.ReverseComplemented())
+
+		lifted, err = db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr3, 5100)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(5460), lifted.Position)
+
+		allele := types.Allele{Reference: "GATc", Alternate: "A"}.ReverseComplement()
+		assert.Equal(t, "gATC", allele.Reference)
+		assert.Equal(t, "T", allele.Alternate)
+	})
+
+	t.Run("Unmapped", func(t *testing.T) {
+		_, err := db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr1, 100)
+		assert.ErrorIs(t, err, genobase.ErrUnmapped)
+
+		_, err = db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr3, 100000)
+		assert.ErrorIs(t, err, genobase.ErrUnmapped)
+	})
+
+	t.Run("Gap", func(t *testing.T) {
+		_, err := db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr1, 217480)
+		assert.ErrorIs(t, err, genobase.ErrInGap)
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		_, err := db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr2, 1121)
+		assert.ErrorIs(t, err, genobase.ErrDeleted)
+	})
+
+	t.Run("Region", func(t *testing.T) {
+		lifted, err := db.LiftoverRegion(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38,
+			types.Region{Chromosome: types.Chr1, Start: 10000, End: 20000})
+		require.NoError(t, err)
+
+		require.Len(t, lifted, 1)
+		assert.Equal(t, types.ReferenceGRCh38, lifted[0].Reference)
+		assert.Equal(t, types.Chr1, lifted[0].Chromosome)
+		assert.Equal(t, int64(10000), lifted[0].Start)
+		assert.Equal(t, int64(20000), lifted[0].End)
+		assert.Equal(t, "+", lifted[0].Strand)
+		assert.Equal(t, int64(10000), lifted[0].MappedBases)
+		assert.Equal(t, 1.0, lifted[0].Coverage)
+
+		lifted, err = db.LiftoverRegion(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38,
+			types.Region{Chromosome: types.Chr3, Start: 5000, End: 5010})
+		require.NoError(t, err)
+
+		require.Len(t, lifted, 1)
+		assert.Equal(t, int64(5549), lifted[0].Start)
+		assert.Equal(t, int64(5559), lifted[0].End)
+		assert.Equal(t, "-", lifted[0].Strand)
+	})
+
+	t.Run("RegionMinMatch", func(t *testing.T) {
+		region := types.Region{Chromosome: types.Chr1, Start: 160000, End: 230000}
+
+		_, err := db.LiftoverRegion(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, region)
+		assert.ErrorIs(t, err, genobase.ErrMinMatch)
+
+		lifted, err := db.LiftoverRegion(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, region,
+			genobase.MinMatch(0.2))
+		require.NoError(t, err)
+
+		require.Len(t, lifted, 1)
+		assert.Equal(t, int64(160000), lifted[0].Start)
+		assert.Equal(t, int64(260249), lifted[0].End)
+		assert.Equal(t, int64(20000), lifted[0].MappedBases)
+		assert.InDelta(t, 20000.0/70000.0, lifted[0].Coverage, 1e-9)
+	})
+
+	t.Run("RegionSplit", func(t *testing.T) {
+		lifted, err := db.LiftoverRegion(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38,
+			types.Region{Chromosome: types.Chr1, Start: 160000, End: 230000},
+			genobase.MinMatch(0.2), genobase.Split)
+		require.NoError(t, err)
+
+		require.Len(t, lifted, 2)
+		assert.Equal(t, types.Region{Chromosome: types.Chr1, Start: 160000, End: 177417}, lifted[0].Source)
+		assert.Equal(t, int64(160000), lifted[0].Start)
+		assert.Equal(t, int64(177417), lifted[0].End)
+		assert.Equal(t, int64(17417), lifted[0].MappedBases)
+		assert.Equal(t, types.Region{Chromosome: types.Chr1, Start: 227417, End: 230000}, lifted[1].Source)
+		assert.Equal(t, int64(257666), lifted[1].Start)
+		assert.Equal(t, int64(260249), lifted[1].End)
+		assert.Equal(t, int64(2583), lifted[1].MappedBases)
+
+		// Deletions in the target assembly don't split the region.
+		lifted, err = db.LiftoverRegion(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38,
+			types.Region{Chromosome: types.Chr2, Start: 1000, End: 1250},
+			genobase.MinMatch(0.8), genobase.Split)
+		require.NoError(t, err)
+
+		require.Len(t, lifted, 1)
+		assert.Equal(t, int64(2000), lifted[0].Start)
+		assert.Equal(t, int64(2200), lifted[0].End)
+		assert.Equal(t, int64(200), lifted[0].MappedBases)
+	})
+
+	t.Run("Path", func(t *testing.T) {
+		for _, chain := range []types.Chain{
+			{
+				Score: 1, Ref: types.ReferenceNCBI36, RefName: "1", RefSize: 247249719, RefStrand: "+", RefStart: 0, RefEnd: 1000,
+				QueryName: "1", QuerySize: 249250621, QueryStrand: "+", QueryStart: 10000, QueryEnd: 11000,
+			},
+			{
+				Score: 1, Ref: types.ReferenceNCBI36, RefName: "2", RefSize: 242951149, RefStrand: "+", RefStart: 0, RefEnd: 100,
+				QueryName: "2", QuerySize: 243199373, QueryStrand: "+", QueryStart: 5000, QueryEnd: 5100,
+			},
+			{
+				Score: 1, Ref: types.ReferenceNCBI36, RefName: "3", RefSize: 199501827, RefStrand: "+", RefStart: 0, RefEnd: 100,
+				QueryName: "3", QuerySize: 198022430, QueryStrand: "-", QueryStart: 198017330, QueryEnd: 198017430,
+			},
+		} {
+			chainID, err := db.StoreChain(ctx, types.ReferenceNCBI36, types.ReferenceGRCh37, &chain)
+			require.NoError(t, err)
+
+			require.NoError(t, db.StoreAlignments(ctx, chainID, []types.Alignment{
+				{RefOffset: 0, QueryOffset: 0, Size: chain.RefEnd - chain.RefStart},
+			}))
+		}
+
+		path := []types.Reference{types.ReferenceNCBI36, types.ReferenceGRCh37, types.ReferenceGRCh38}
+
+		lifted, err := db.LiftoverPath(ctx, path, types.Chr1, 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.ReferenceGRCh38, lifted.Reference)
+		assert.Equal(t, types.Chr1, lifted.Chromosome)
+		assert.Equal(t, int64(10001), lifted.Position)
+		assert.Equal(t, "+", lifted.Strand)
+
+		// Reverse strand twice is the forward strand.
+		lifted, err = db.LiftoverPath(ctx, path, types.Chr3, 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.Chr3, lifted.Chromosome)
+		assert.Equal(t, int64(5460), lifted.Position)
+		assert.Equal(t, "+", lifted.Strand)
+
+		_, err = db.LiftoverPath(ctx, path, types.Chr2, 1)
+		assert.ErrorIs(t, err, genobase.ErrUnmapped)
+
+		var hopErr *genobase.HopError
+		require.ErrorAs(t, err, &hopErr)
+		assert.Equal(t, types.ReferenceGRCh37, hopErr.From)
+		assert.Equal(t, types.ReferenceGRCh38, hopErr.To)
+
+		// Without an explicit path.
+		lifted, taken, err := db.LiftoverMultiHop(ctx, types.ReferenceNCBI36, types.ReferenceGRCh38, types.Chr1, 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, path, taken)
+		assert.Equal(t, int64(10001), lifted.Position)
+
+		_, err = db.FindLiftoverPath(ctx, types.ReferenceGRCh38, types.ReferenceNCBI36)
+		assert.ErrorIs(t, err, genobase.ErrNoLiftoverPath)
+	})
+
+	t.Run("TargetAssembly", func(t *testing.T) {
+		chainID, err := db.StoreChain(ctx, types.ReferenceGRCh37, types.ReferenceTelomereToTelomereV2, &types.Chain{
+			Score:       10,
+			RefName:     "1",
+			RefSize:     249250621,
+			RefStrand:   "+",
+			RefStart:    10000,
+			RefEnd:      20000,
+			QueryName:   "1",
+			QuerySize:   248387328,
+			QueryStrand: "+",
+			QueryStart:  0,
+			QueryEnd:    10000,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, db.StoreAlignments(ctx, chainID, []types.Alignment{
+			{RefOffset: 0, QueryOffset: 0, Size: 10000},
+		}))
+
+		lifted, err := db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceTelomereToTelomereV2, types.Chr1, 10001)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.ReferenceTelomereToTelomereV2, lifted.Reference)
+		assert.Equal(t, int64(1), lifted.Position)
+
+		// The higher scoring chain to another assembly is ignored.
+		lifted, err = db.Liftover(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, types.Chr1, 10001)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(10001), lifted.Position)
+
+		chain, err := db.GetChain(ctx, types.ReferenceGRCh37, types.ReferenceTelomereToTelomereV2, types.Chr1, 10001)
+		require.NoError(t, err)
+
+		assert.Equal(t, chainID, chain.ID)
+		assert.Equal(t, types.ReferenceGRCh37, chain.Ref)
+		assert.Equal(t, types.ReferenceTelomereToTelomereV2, chain.QueryRef)
+	})
+}
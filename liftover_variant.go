@@ -0,0 +1,227 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/zymatik-com/genobase/types"
+)
+
+// LiftoverVariant lifts a variant, along with its alleles, over to another
+// reference genome assembly. The variant is lifted from its position on the
+// source assembly (see Variant.Positions), and the lifted variant is positioned
+// on the target assembly. Indel positions on assemblies other than GRCh38 are
+// only left-aligned if the sequence of the assembly was imported before its
+// dbSNP release (see ImportDbSNP).
+//
+// The reference alleles are checked against the sequence of the target assembly
+// (from the sequence source if set, see SetSequenceSource, otherwise from the
+// imported sequence), if there is one for the chromosome. When the reference
+// base has changed between assemblies, the reference and alternate alleles of
+// biallelic SNVs/MNVs are swapped (as with Picard LiftoverVcf). The target
+// sequence is also required to re-anchor indels that map to the reverse strand.
+//
+// Variants that cannot be lifted over are returned unchanged, with
+// LiftoverStatusRejected.
+func (db *DB) LiftoverVariant(ctx context.Context, from, to types.Reference, variant *types.Variant) (*types.LiftedVariant, error) {
+	source := types.Locus{Chromosome: variant.Chromosome, Position: variant.Position}
+	if from != types.ReferenceGRCh38 {
+		var ok bool
+		source, ok = variant.Positions[from]
+		if !ok {
+			// Only GetVariant fills in the positions of a variant.
+			positions, err := db.GetVariantPositions(ctx, variant.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			source, ok = positions[from]
+			if !ok {
+				return nil, fmt.Errorf("variant %d has no %s position: %w", variant.ID, from, os.ErrNotExist)
+			}
+		}
+	}
+
+	original, err := db.GetAlleles(ctx, variant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on a copy of the alleles, so that rejected variants can be returned
+	// with their original alleles.
+	alleles := slices.Clone(original)
+	seq := db.referenceSequence()
+
+	lifted := &types.LiftedVariant{
+		Variant: *variant,
+		Alleles: alleles,
+		Status:  types.LiftoverStatusClean,
+	}
+	lifted.Variant.Positions = maps.Clone(variant.Positions)
+	if lifted.Variant.Positions == nil {
+		lifted.Variant.Positions = make(map[types.Reference]types.Locus)
+	}
+
+	reject := func(reason string) (*types.LiftedVariant, error) {
+		unchanged := *variant
+		unchanged.Positions = maps.Clone(variant.Positions)
+
+		return &types.LiftedVariant{
+			Variant: unchanged,
+			Alleles: original,
+			Status:  types.LiftoverStatusRejected,
+			Reason:  reason,
+		}, nil
+	}
+
+	// The number of reference bases spanned by the variant.
+	span := int64(1)
+	for _, allele := range alleles {
+		span = max(span, int64(len(allele.Reference)))
+	}
+
+	var chromosome types.Chromosome
+	var start int64
+	var strand string
+	if span == 1 {
+		position, err := db.Liftover(ctx, from, to, source.Chromosome, source.Position)
+		if err != nil {
+			if isLiftoverError(err) {
+				return reject(err.Error())
+			}
+
+			return nil, err
+		}
+
+		chromosome, start, strand = position.Chromosome, position.Position-1, position.Strand
+	} else {
+		regions, err := db.LiftoverRegion(ctx, from, to, types.Region{
+			Chromosome: source.Chromosome,
+			Start:      source.Position - 1,
+			End:        source.Position - 1 + span,
+		}, MinMatch(1))
+		if err != nil {
+			if isLiftoverError(err) {
+				return reject(err.Error())
+			}
+
+			return nil, err
+		}
+
+		// All the reference bases must map contiguously.
+		if len(regions) != 1 || regions[0].End-regions[0].Start != span {
+			return reject("variant spans an alignment gap")
+		}
+
+		chromosome, start, strand = regions[0].Chromosome, regions[0].Start, regions[0].Strand
+	}
+
+	lifted.Variant.Chromosome = chromosome
+	lifted.Variant.Position = start + 1
+
+	if strand == "-" {
+		lifted.ReverseComplemented = true
+
+		var indel bool
+		for i := range alleles {
+			if alleles[i].Reference != alleles[0].Reference {
+				return reject("alleles on the reverse strand have differing reference alleles")
+			}
+
+			alleles[i] = alleles[i].ReverseComplement()
+			indel = indel || len(alleles[i].Reference) != len(alleles[i].Alternate)
+		}
+
+		// After reverse complementing, the padding base of an indel is at the end
+		// of the alleles, rather than the start, so it needs to be re-anchored on
+		// the preceding base.
+		if indel {
+			padding, err := seq.GetSequence(ctx, to, chromosome, start-1, start)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return reject("indels on the reverse strand require the target reference sequence")
+				}
+
+				return nil, fmt.Errorf("could not get reference sequence: %w", err)
+			}
+
+			for i := range alleles {
+				ref, alt := alleles[i].Reference, alleles[i].Alternate
+				if ref == "" || alt == "" || ref[len(ref)-1] != alt[len(alt)-1] {
+					return reject("indel alleles do not share a padding base")
+				}
+
+				alleles[i].Reference = padding + ref[:len(ref)-1]
+				alleles[i].Alternate = padding + alt[:len(alt)-1]
+			}
+
+			lifted.Variant.Position = start
+		}
+	}
+
+	lifted.Variant.Positions[to] = types.Locus{
+		Chromosome: lifted.Variant.Chromosome,
+		Position:   lifted.Variant.Position,
+	}
+
+	for i := range alleles {
+		ref, alt := alleles[i].Reference, alleles[i].Alternate
+
+		target, err := seq.GetSequence(ctx, to, chromosome, lifted.Variant.Position-1, lifted.Variant.Position-1+int64(len(ref)))
+		if err != nil {
+			// Without the target sequence, the alleles can't be checked.
+			if errors.Is(err, os.ErrNotExist) {
+				return lifted, nil
+			}
+
+			return nil, fmt.Errorf("could not get reference sequence: %w", err)
+		}
+
+		if strings.EqualFold(target, ref) {
+			continue
+		}
+
+		// We can only swap the alleles of biallelic variants that don't change length.
+		if len(alleles) == 1 && len(ref) == len(alt) && strings.EqualFold(target, alt) {
+			alleles[i].Reference, alleles[i].Alternate = alt, ref
+			alleles[i].Frequency = 1 - alleles[i].Frequency
+			if alleles[i].AlleleCount != nil && alleles[i].AlleleNumber != nil {
+				count := *alleles[i].AlleleNumber - *alleles[i].AlleleCount
+				alleles[i].AlleleCount = &count
+			}
+			// We don't know how many individuals are homozygous for the old reference allele.
+			alleles[i].HomozygoteCount = nil
+			alleles[i].HemizygoteCount = nil
+			lifted.Status = types.LiftoverStatusSwapped
+			continue
+		}
+
+		return reject(fmt.Sprintf("reference allele %s does not match target assembly (%s)", ref, target))
+	}
+
+	return lifted, nil
+}
+
+// isLiftoverError returns true if the error is due to a position or region
+// not being able to be lifted over.
+func isLiftoverError(err error) bool {
+	return errors.Is(err, ErrUnmapped) || errors.Is(err, ErrInGap) ||
+		errors.Is(err, ErrDeleted) || errors.Is(err, ErrMinMatch)
+}
@@ -0,0 +1,261 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestLiftoverVariant(t *testing.T) {
+	ctx := context.Background()
+
+	db := openLiftoverDB(t)
+
+	grch37 := func(chromosome types.Chromosome, position int64) map[types.Reference]types.Locus {
+		return map[types.Reference]types.Locus{
+			types.ReferenceGRCh37: {Chromosome: chromosome, Position: position},
+		}
+	}
+
+	// Variants are lifted from their GRCh37 positions.
+	require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+		{ID: 1, Chromosome: types.Chr1, Position: 10001, Class: types.VariantClassSNV, Positions: grch37(types.Chr1, 10001)},
+		{ID: 2, Chromosome: types.Chr1, Position: 10002, Class: types.VariantClassSNV, Positions: grch37(types.Chr1, 10002)},
+		{ID: 3, Chromosome: types.Chr3, Position: 5559, Class: types.VariantClassSNV, Positions: grch37(types.Chr3, 5001)},
+		{ID: 4, Chromosome: types.Chr3, Position: 5548, Class: types.VariantClassDEL, Positions: grch37(types.Chr3, 5010)},
+		{ID: 5, Chromosome: types.Chr1, Position: 217480, Class: types.VariantClassSNV, Positions: grch37(types.Chr1, 217480)},
+		{ID: 6, Chromosome: types.Chr1, Position: 10003, Class: types.VariantClassSNV, Positions: grch37(types.Chr1, 10003)},
+		{ID: 7, Chromosome: types.Chr1, Position: 10004, Class: types.VariantClassSNV},
+	}))
+
+	require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+		{ID: 1, Reference: "G", Alternate: "A", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+		{ID: 2, Reference: "C", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.2},
+		{ID: 3, Reference: "C", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.3},
+		{ID: 4, Reference: "CA", Alternate: "C", Ancestry: types.AncestryGroupAll, Frequency: 0.4},
+		{ID: 5, Reference: "G", Alternate: "A", Ancestry: types.AncestryGroupAll, Frequency: 0.5},
+		{ID: 6, Reference: "A", Alternate: "G", Ancestry: types.AncestryGroupAll, Frequency: 0.6},
+	}))
+
+	seq := fakeSequence{
+		{Chromosome: types.Chr1, Position: 10001}: 'G',
+		{Chromosome: types.Chr1, Position: 10002}: 'T',
+		{Chromosome: types.Chr1, Position: 10003}: 'C',
+		{Chromosome: types.Chr3, Position: 5548}:  'A',
+		{Chromosome: types.Chr3, Position: 5549}:  'T',
+		{Chromosome: types.Chr3, Position: 5559}:  'G',
+	}
+
+	liftover := func(t *testing.T, id int64, seq genobase.SequenceSource) *types.LiftedVariant {
+		variant, err := db.GetVariant(ctx, id)
+		require.NoError(t, err)
+
+		db.SetSequenceSource(seq)
+		t.Cleanup(func() {
+			db.SetSequenceSource(nil)
+		})
+
+		lifted, err := db.LiftoverVariant(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, variant)
+		require.NoError(t, err)
+
+		return lifted
+	}
+
+	t.Run("Clean", func(t *testing.T) {
+		lifted := liftover(t, 1, seq)
+
+		assert.Equal(t, types.LiftoverStatusClean, lifted.Status)
+		assert.False(t, lifted.ReverseComplemented)
+		assert.Equal(t, types.Chr1, lifted.Variant.Chromosome)
+		assert.Equal(t, int64(10001), lifted.Variant.Position)
+		assert.Equal(t, types.Locus{Chromosome: types.Chr1, Position: 10001}, lifted.Variant.Positions[types.ReferenceGRCh38])
+		require.Len(t, lifted.Alleles, 1)
+		assert.Equal(t, "G", lifted.Alleles[0].Reference)
+		assert.Equal(t, "A", lifted.Alleles[0].Alternate)
+	})
+
+	t.Run("Swapped", func(t *testing.T) {
+		lifted := liftover(t, 2, seq)
+
+		assert.Equal(t, types.LiftoverStatusSwapped, lifted.Status)
+		require.Len(t, lifted.Alleles, 1)
+		assert.Equal(t, "T", lifted.Alleles[0].Reference)
+		assert.Equal(t, "C", lifted.Alleles[0].Alternate)
+		assert.InDelta(t, 0.8, lifted.Alleles[0].Frequency, 1e-9)
+	})
+
+	t.Run("ReverseComplemented", func(t *testing.T) {
+		lifted := liftover(t, 3, seq)
+
+		assert.Equal(t, types.LiftoverStatusClean, lifted.Status)
+		assert.True(t, lifted.ReverseComplemented)
+		assert.Equal(t, types.Chr3, lifted.Variant.Chromosome)
+		assert.Equal(t, int64(5559), lifted.Variant.Position)
+		assert.Equal(t, types.Locus{Chromosome: types.Chr3, Position: 5559}, lifted.Variant.Positions[types.ReferenceGRCh38])
+		assert.Equal(t, types.Locus{Chromosome: types.Chr3, Position: 5001}, lifted.Variant.Positions[types.ReferenceGRCh37])
+		require.Len(t, lifted.Alleles, 1)
+		assert.Equal(t, "G", lifted.Alleles[0].Reference)
+		assert.Equal(t, "A", lifted.Alleles[0].Alternate)
+	})
+
+	t.Run("ReverseComplementedIndel", func(t *testing.T) {
+		lifted := liftover(t, 4, seq)
+
+		assert.Equal(t, types.LiftoverStatusClean, lifted.Status, lifted.Reason)
+		assert.True(t, lifted.ReverseComplemented)
+		assert.Equal(t, int64(5548), lifted.Variant.Position)
+		assert.Equal(t, types.Locus{Chromosome: types.Chr3, Position: 5548}, lifted.Variant.Positions[types.ReferenceGRCh38])
+		require.Len(t, lifted.Alleles, 1)
+		assert.Equal(t, "AT", lifted.Alleles[0].Reference)
+		assert.Equal(t, "A", lifted.Alleles[0].Alternate)
+
+		// Without the reference sequence we can't re-anchor the indel.
+		lifted = liftover(t, 4, nil)
+
+		assert.Equal(t, types.LiftoverStatusRejected, lifted.Status)
+		assert.False(t, lifted.ReverseComplemented)
+
+		// Rejected variants are returned unchanged.
+		assert.Equal(t, types.Locus{Chromosome: types.Chr3, Position: 5010}, lifted.Variant.Positions[types.ReferenceGRCh37])
+		require.Len(t, lifted.Alleles, 1)
+		assert.Equal(t, "CA", lifted.Alleles[0].Reference)
+		assert.Equal(t, "C", lifted.Alleles[0].Alternate)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		lifted := liftover(t, 5, seq)
+
+		assert.Equal(t, types.LiftoverStatusRejected, lifted.Status)
+		assert.Contains(t, lifted.Reason, "gap")
+
+		lifted = liftover(t, 6, seq)
+
+		assert.Equal(t, types.LiftoverStatusRejected, lifted.Status)
+		assert.Contains(t, lifted.Reason, "does not match")
+	})
+
+	t.Run("Positions", func(t *testing.T) {
+		variant, err := db.GetVariant(ctx, 3)
+		require.NoError(t, err)
+
+		// The GRCh38 position is not used when lifting from GRCh37.
+		variant.Position = 6000
+
+		lifted, err := db.LiftoverVariant(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, variant)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(5559), lifted.Variant.Position)
+
+		// The positions of the original variant are left alone.
+		assert.Equal(t, types.Locus{Chromosome: types.Chr3, Position: 5559}, variant.Positions[types.ReferenceGRCh38])
+
+		// Variants without a position on the source assembly can't be lifted.
+		variant, err = db.GetVariant(ctx, 7)
+		require.NoError(t, err)
+
+		_, err = db.LiftoverVariant(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, variant)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		// Variants from bulk lookups don't have their positions filled in.
+		variants, err := db.GetVariantsByIDs(ctx, []int64{3})
+		require.NoError(t, err)
+		require.Contains(t, variants, int64(3))
+
+		found := variants[3]
+		assert.Nil(t, found.Positions)
+
+		lifted, err = db.LiftoverVariant(ctx, types.ReferenceGRCh37, types.ReferenceGRCh38, &found)
+		require.NoError(t, err)
+
+		assert.Equal(t, types.Chr3, lifted.Variant.Chromosome)
+		assert.Equal(t, int64(5559), lifted.Variant.Position)
+	})
+}
+
+// fakeSequence is an in-memory reference sequence, unknown bases are N.
+type fakeSequence map[types.Locus]byte
+
+func (s fakeSequence) GetSequence(_ context.Context, _ types.Reference, chromosome types.Chromosome, start, end int64) (string, error) {
+	var sb strings.Builder
+	for position := start + 1; position <= end; position++ {
+		base, ok := s[types.Locus{Chromosome: chromosome, Position: position}]
+		if !ok {
+			base = 'N'
+		}
+
+		sb.WriteByte(base)
+	}
+
+	return sb.String(), nil
+}
+
+func TestLiftoverVariantImportedSequence(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	chainID, err := db.StoreChain(ctx, types.ReferenceGRCh38, types.ReferenceGRCh37, &types.Chain{
+		Score:       1,
+		Ref:         types.ReferenceGRCh38,
+		RefName:     "1",
+		RefSize:     20,
+		RefStrand:   "+",
+		RefStart:    0,
+		RefEnd:      20,
+		QueryName:   "1",
+		QuerySize:   20,
+		QueryStrand: "+",
+		QueryStart:  0,
+		QueryEnd:    20,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.StoreAlignments(ctx, chainID, []types.Alignment{
+		{RefOffset: 0, QueryOffset: 0, Size: 20},
+	}))
+
+	// Only the sequence of the target assembly is imported.
+	require.NoError(t, db.ImportSequence(ctx, types.ReferenceGRCh37, strings.NewReader(">chr1\nGGGGTGGGGGGGGGGGGGGG\n")))
+
+	require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+		{ID: 1, Chromosome: types.Chr1, Position: 5, Class: types.VariantClassSNV},
+	}))
+
+	require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+		{ID: 1, Reference: "C", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+	}))
+
+	variant, err := db.GetVariant(ctx, 1)
+	require.NoError(t, err)
+
+	lifted, err := db.LiftoverVariant(ctx, types.ReferenceGRCh38, types.ReferenceGRCh37, variant)
+	require.NoError(t, err)
+
+	assert.Equal(t, types.LiftoverStatusSwapped, lifted.Status)
+	require.Len(t, lifted.Alleles, 1)
+	assert.Equal(t, "T", lifted.Alleles[0].Reference)
+	assert.Equal(t, "C", lifted.Alleles[0].Alternate)
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Liftover looks up the alignment blocks surrounding an offset within a 
+-- single chain, so index both columns together.
+CREATE INDEX liftover_alignment_chain_offset ON liftover_alignment(chain_id, ref_offset);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX liftover_alignment_chain_offset;
+
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The reference genome assembly of the query (target) side of the chain, so 
+-- that we can store chains from the same source assembly to multiple targets, 
+-- eg. hg38ToHg19 and hg38ToT2T. Previously imported chains are backfilled
+-- (see 20240205101122_liftover_chain_query_ref_not_null.sql).
+ALTER TABLE liftover_chain ADD COLUMN query_ref TEXT REFERENCES reference (id);
+
+DROP INDEX liftover_chain_ref_name;
+CREATE INDEX liftover_chain_ref_name ON liftover_chain(ref, query_ref, ref_name);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- SQLite can't drop a column that references another table, so the table is
+-- rebuilt (keeping the chain IDs, which are referenced by the alignments).
+CREATE TABLE liftover_chain_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    score INTEGER,
+    ref TEXT,
+    ref_name TEXT,
+    ref_size INTEGER,
+    ref_strand TEXT,
+    ref_start INTEGER,
+    ref_end INTEGER,
+    query_name TEXT,
+    query_size INTEGER,
+    query_strand TEXT,
+    query_start INTEGER,
+    query_end INTEGER,
+    FOREIGN KEY (ref) REFERENCES reference (id),
+    FOREIGN KEY (ref_name) REFERENCES chromosome (id),
+    FOREIGN KEY (query_name) REFERENCES chromosome (id)
+);
+
+INSERT INTO liftover_chain_old
+    SELECT id, score, ref, ref_name, ref_size, ref_strand, ref_start, ref_end,
+        query_name, query_size, query_strand, query_start, query_end
+    FROM liftover_chain;
+
+DROP TABLE liftover_chain;
+ALTER TABLE liftover_chain_old RENAME TO liftover_chain;
+
+CREATE INDEX liftover_chain_ref_name ON liftover_chain(ref, ref_name);
+CREATE INDEX liftover_chain_ref_start ON liftover_chain(ref_start);
+CREATE INDEX liftover_chain_ref_end ON liftover_chain(ref_end);
+
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- gnomAD groups individuals not assigned to any other ancestry group as
+-- "other" (v3) or "remaining" (v4).
+INSERT INTO ancestry_group (id, description) VALUES
+    ('OTH', 'Other (population not assigned)');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM ancestry_group WHERE id = 'OTH';
+
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The number of alternate alleles observed (AC).
+ALTER TABLE allele ADD COLUMN allele_count INTEGER;
+-- The total number of alleles genotyped (AN).
+ALTER TABLE allele ADD COLUMN allele_number INTEGER;
+-- The number of individuals homozygous for the alternate allele.
+ALTER TABLE allele ADD COLUMN homozygote_count INTEGER;
+-- The number of individuals hemizygous for the alternate allele 
+-- (eg. XY individuals outside of the pseudoautosomal regions).
+ALTER TABLE allele ADD COLUMN hemizygote_count INTEGER;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE allele DROP COLUMN hemizygote_count;
+ALTER TABLE allele DROP COLUMN homozygote_count;
+ALTER TABLE allele DROP COLUMN allele_number;
+ALTER TABLE allele DROP COLUMN allele_count;
+
+-- +goose StatementEnd
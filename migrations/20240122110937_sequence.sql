@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The `sequence` table stores the chromosomes of each reference genome 
+-- assembly we have the sequence for.
+CREATE TABLE sequence (
+    -- Reference genome assembly name.
+    ref TEXT NOT NULL,
+    -- Chromosome name.
+    chromosome TEXT NOT NULL,
+    -- Length of the chromosome in bases.
+    length INTEGER NOT NULL,
+    -- Number of bases stored in each chunk.
+    chunk_size INTEGER NOT NULL,
+    PRIMARY KEY (ref, chromosome),
+    FOREIGN KEY (ref) REFERENCES reference (id),
+    FOREIGN KEY (chromosome) REFERENCES chromosome (id)
+);
+
+-- The `sequence_chunk` table stores the sequence of each chromosome in 
+-- fixed size chunks (so that any interval can be retrieved efficiently).
+-- Bases are packed two per byte using 4-bit codes (as in BAM files).
+CREATE TABLE sequence_chunk (
+    -- Reference genome assembly name.
+    ref TEXT NOT NULL,
+    -- Chromosome name.
+    chromosome TEXT NOT NULL,
+    -- Index of the chunk (the offset of its first base / chunk_size).
+    chunk INTEGER NOT NULL,
+    -- Packed bases.
+    bases BLOB NOT NULL,
+    PRIMARY KEY (ref, chromosome, chunk),
+    FOREIGN KEY (ref, chromosome) REFERENCES sequence (ref, chromosome)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE sequence_chunk;
+
+DROP TABLE sequence;
+
+-- +goose StatementEnd
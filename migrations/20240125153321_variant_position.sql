@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The `variant_position` table stores the position of each variant on every 
+-- reference genome assembly it is known on. The `variant` table holds the 
+-- GRCh38 position, which is also stored here.
+CREATE TABLE variant_position (
+    -- The RSID of the variant.
+    id INTEGER NOT NULL,
+    -- Reference genome assembly name.
+    ref TEXT NOT NULL,
+    -- The chromosome on which the variant is located.
+    chromosome TEXT,
+    -- The position of the variant on the chromosome.
+    position INTEGER,
+    PRIMARY KEY (id, ref),
+    FOREIGN KEY (id) REFERENCES variant (id),
+    FOREIGN KEY (ref) REFERENCES reference (id),
+    FOREIGN KEY (chromosome) REFERENCES chromosome (id)
+);
+CREATE INDEX variant_position_coordinate ON variant_position(ref, chromosome, position);
+
+INSERT INTO variant_position (id, ref, chromosome, position)
+    SELECT id, 'GRCh38', chromosome, position FROM variant;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE variant_position;
+
+-- +goose StatementEnd
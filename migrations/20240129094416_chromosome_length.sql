@@ -0,0 +1,138 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The `chromosome_length` table stores the length of each chromosome on each
+-- reference genome assembly. The lengths of the pseudoautosomal regions are 
+-- those on chromosome X.
+CREATE TABLE chromosome_length (
+    -- Reference genome assembly name.
+    ref TEXT NOT NULL,
+    -- Chromosome name.
+    chromosome TEXT NOT NULL,
+    -- Length of the chromosome in bases.
+    length INTEGER NOT NULL,
+    PRIMARY KEY (ref, chromosome),
+    FOREIGN KEY (ref) REFERENCES reference (id),
+    FOREIGN KEY (chromosome) REFERENCES chromosome (id)
+);
+
+-- Populate the `chromosome_length` table for the supported reference genome
+-- assemblies (other assemblies can be added by importing a FASTA index).
+INSERT INTO chromosome_length (ref, chromosome, length) VALUES
+    ('NCBI36', '1', 247249719),
+    ('NCBI36', '2', 242951149),
+    ('NCBI36', '3', 199501827),
+    ('NCBI36', '4', 191273063),
+    ('NCBI36', '5', 180857866),
+    ('NCBI36', '6', 170899992),
+    ('NCBI36', '7', 158821424),
+    ('NCBI36', '8', 146274826),
+    ('NCBI36', '9', 140273252),
+    ('NCBI36', '10', 135374737),
+    ('NCBI36', '11', 134452384),
+    ('NCBI36', '12', 132349534),
+    ('NCBI36', '13', 114142980),
+    ('NCBI36', '14', 106368585),
+    ('NCBI36', '15', 100338915),
+    ('NCBI36', '16', 88827254),
+    ('NCBI36', '17', 78774742),
+    ('NCBI36', '18', 76117153),
+    ('NCBI36', '19', 63811651),
+    ('NCBI36', '20', 62435964),
+    ('NCBI36', '21', 46944323),
+    ('NCBI36', '22', 49691432),
+    ('NCBI36', 'X', 154913754),
+    ('NCBI36', 'Y', 57772954),
+    ('NCBI36', 'MT', 16571),
+    ('NCBI36', 'PAR', 2709520),
+    ('NCBI36', 'PAR2', 329517),
+    ('GRCh37', '1', 249250621),
+    ('GRCh37', '2', 243199373),
+    ('GRCh37', '3', 198022430),
+    ('GRCh37', '4', 191154276),
+    ('GRCh37', '5', 180915260),
+    ('GRCh37', '6', 171115067),
+    ('GRCh37', '7', 159138663),
+    ('GRCh37', '8', 146364022),
+    ('GRCh37', '9', 141213431),
+    ('GRCh37', '10', 135534747),
+    ('GRCh37', '11', 135006516),
+    ('GRCh37', '12', 133851895),
+    ('GRCh37', '13', 115169878),
+    ('GRCh37', '14', 107349540),
+    ('GRCh37', '15', 102531392),
+    ('GRCh37', '16', 90354753),
+    ('GRCh37', '17', 81195210),
+    ('GRCh37', '18', 78077248),
+    ('GRCh37', '19', 59128983),
+    ('GRCh37', '20', 63025520),
+    ('GRCh37', '21', 48129895),
+    ('GRCh37', '22', 51304566),
+    ('GRCh37', 'X', 155270560),
+    ('GRCh37', 'Y', 59373566),
+    ('GRCh37', 'MT', 16569),
+    ('GRCh37', 'PAR', 2639520),
+    ('GRCh37', 'PAR2', 329517),
+    ('GRCh38', '1', 248956422),
+    ('GRCh38', '2', 242193529),
+    ('GRCh38', '3', 198295559),
+    ('GRCh38', '4', 190214555),
+    ('GRCh38', '5', 181538259),
+    ('GRCh38', '6', 170805979),
+    ('GRCh38', '7', 159345973),
+    ('GRCh38', '8', 145138636),
+    ('GRCh38', '9', 138394717),
+    ('GRCh38', '10', 133797422),
+    ('GRCh38', '11', 135086622),
+    ('GRCh38', '12', 133275309),
+    ('GRCh38', '13', 114364328),
+    ('GRCh38', '14', 107043718),
+    ('GRCh38', '15', 101991189),
+    ('GRCh38', '16', 90338345),
+    ('GRCh38', '17', 83257441),
+    ('GRCh38', '18', 80373285),
+    ('GRCh38', '19', 58617616),
+    ('GRCh38', '20', 64444167),
+    ('GRCh38', '21', 46709983),
+    ('GRCh38', '22', 50818468),
+    ('GRCh38', 'X', 156040895),
+    ('GRCh38', 'Y', 57227415),
+    ('GRCh38', 'MT', 16569),
+    ('GRCh38', 'PAR', 2771479),
+    ('GRCh38', 'PAR2', 329513),
+    ('T2T-CHM13v2.0', '1', 248387328),
+    ('T2T-CHM13v2.0', '2', 242696752),
+    ('T2T-CHM13v2.0', '3', 201105948),
+    ('T2T-CHM13v2.0', '4', 193574945),
+    ('T2T-CHM13v2.0', '5', 182045439),
+    ('T2T-CHM13v2.0', '6', 172126628),
+    ('T2T-CHM13v2.0', '7', 160567428),
+    ('T2T-CHM13v2.0', '8', 146259331),
+    ('T2T-CHM13v2.0', '9', 150617247),
+    ('T2T-CHM13v2.0', '10', 134758134),
+    ('T2T-CHM13v2.0', '11', 135127769),
+    ('T2T-CHM13v2.0', '12', 133324548),
+    ('T2T-CHM13v2.0', '13', 113566686),
+    ('T2T-CHM13v2.0', '14', 101161492),
+    ('T2T-CHM13v2.0', '15', 99753195),
+    ('T2T-CHM13v2.0', '16', 96330374),
+    ('T2T-CHM13v2.0', '17', 84276897),
+    ('T2T-CHM13v2.0', '18', 80542538),
+    ('T2T-CHM13v2.0', '19', 61707364),
+    ('T2T-CHM13v2.0', '20', 66210255),
+    ('T2T-CHM13v2.0', '21', 45090682),
+    ('T2T-CHM13v2.0', '22', 51324926),
+    ('T2T-CHM13v2.0', 'X', 154259566),
+    ('T2T-CHM13v2.0', 'Y', 62460029),
+    ('T2T-CHM13v2.0', 'MT', 16569),
+    ('T2T-CHM13v2.0', 'PAR', 2394410),
+    ('T2T-CHM13v2.0', 'PAR2', 333732);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE chromosome_length;
+
+-- +goose StatementEnd
@@ -0,0 +1,95 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Chains imported before the target assembly was recorded have no query_ref,
+-- and so would never be selected for liftover. At the time, GRCh38 was the 
+-- only supported liftover target, however chains from GRCh38 (eg. hg38ToHg19)
+-- could still have been imported. Their target is unknown, so rather than
+-- backfilling them as GRCh38 to GRCh38 chains, they are deleted and must be
+-- imported again.
+DELETE FROM liftover_alignment WHERE chain_id IN
+    (SELECT id FROM liftover_chain WHERE query_ref IS NULL AND ref = 'GRCh38');
+DELETE FROM liftover_chain WHERE query_ref IS NULL AND ref = 'GRCh38';
+
+UPDATE liftover_chain SET query_ref = 'GRCh38' WHERE query_ref IS NULL;
+
+-- SQLite can't add a NOT NULL constraint to an existing column, so the table
+-- is rebuilt (keeping the chain IDs, which are referenced by the alignments).
+CREATE TABLE liftover_chain_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- Alignment score.
+    score INTEGER,
+    -- Reference genome assembly name.
+    ref TEXT,
+    -- Reference chromosome name.
+    ref_name TEXT,
+    -- Size of the reference chromosome.
+    ref_size INTEGER,
+    -- Strand in the reference genome ('+' or '-').
+    ref_strand TEXT,
+    -- Start position in the reference genome.
+    ref_start INTEGER,
+    -- End position in the reference genome.
+    ref_end INTEGER,
+    -- Query chromosome name.
+    query_name TEXT,
+    -- Size of the query chromosome.
+    query_size INTEGER,
+    -- Strand in the query genome ('+' or '-').
+    query_strand TEXT,
+    -- Start position in the query genome.
+    query_start INTEGER,
+    -- End position in the query genome.
+    query_end INTEGER,
+    -- Query (target) genome assembly name.
+    query_ref TEXT NOT NULL,
+    FOREIGN KEY (ref) REFERENCES reference (id),
+    FOREIGN KEY (ref_name) REFERENCES chromosome (id),
+    FOREIGN KEY (query_name) REFERENCES chromosome (id),
+    FOREIGN KEY (query_ref) REFERENCES reference (id)
+);
+
+INSERT INTO liftover_chain_new SELECT * FROM liftover_chain;
+
+DROP TABLE liftover_chain;
+ALTER TABLE liftover_chain_new RENAME TO liftover_chain;
+
+CREATE INDEX liftover_chain_ref_name ON liftover_chain(ref, query_ref, ref_name);
+CREATE INDEX liftover_chain_ref_start ON liftover_chain(ref_start);
+CREATE INDEX liftover_chain_ref_end ON liftover_chain(ref_end);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE TABLE liftover_chain_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    score INTEGER,
+    ref TEXT,
+    ref_name TEXT,
+    ref_size INTEGER,
+    ref_strand TEXT,
+    ref_start INTEGER,
+    ref_end INTEGER,
+    query_name TEXT,
+    query_size INTEGER,
+    query_strand TEXT,
+    query_start INTEGER,
+    query_end INTEGER,
+    query_ref TEXT REFERENCES reference (id),
+    FOREIGN KEY (ref) REFERENCES reference (id),
+    FOREIGN KEY (ref_name) REFERENCES chromosome (id),
+    FOREIGN KEY (query_name) REFERENCES chromosome (id)
+);
+
+INSERT INTO liftover_chain_old SELECT * FROM liftover_chain;
+
+DROP TABLE liftover_chain;
+ALTER TABLE liftover_chain_old RENAME TO liftover_chain;
+
+CREATE INDEX liftover_chain_ref_name ON liftover_chain(ref, query_ref, ref_name);
+CREATE INDEX liftover_chain_ref_start ON liftover_chain(ref_start);
+CREATE INDEX liftover_chain_ref_end ON liftover_chain(ref_end);
+
+-- +goose StatementEnd
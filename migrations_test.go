@@ -0,0 +1,92 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/neilotoole/slogt"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "genobase.db")
+
+	db, err := sqlx.Connect("sqlite3", "file:"+path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	goose.SetBaseFS(os.DirFS("."))
+	require.NoError(t, goose.SetDialect("sqlite3"))
+
+	// Chains imported before the target assembly was recorded.
+	require.NoError(t, goose.UpToContext(ctx, db.DB, "migrations", 20240112093015))
+
+	for _, ref := range []types.Reference{types.ReferenceGRCh37, types.ReferenceGRCh38} {
+		result, err := db.ExecContext(ctx, `INSERT INTO liftover_chain (score, ref, ref_name, ref_size, ref_strand, ref_start, ref_end,
+			query_name, query_size, query_strand, query_start, query_end) VALUES (1000, ?, '1', 1000, '+', 0, 100, '1', 1000, '+', 10, 110)`, ref)
+		require.NoError(t, err)
+
+		id, err := result.LastInsertId()
+		require.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, "INSERT INTO liftover_alignment (chain_id, ref_offset, query_offset, size) VALUES (?, 0, 0, 100)", id)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, goose.UpContext(ctx, db.DB, "migrations"))
+
+	// Chains from GRCh38 have an unknown target, so are deleted.
+	var chains []struct {
+		ID       int64           `db:"id"`
+		Ref      types.Reference `db:"ref"`
+		QueryRef types.Reference `db:"query_ref"`
+	}
+	require.NoError(t, db.SelectContext(ctx, &chains, "SELECT id, ref, query_ref FROM liftover_chain"))
+	require.Len(t, chains, 1)
+	assert.Equal(t, types.ReferenceGRCh37, chains[0].Ref)
+	assert.Equal(t, types.ReferenceGRCh38, chains[0].QueryRef)
+
+	var alignments int
+	require.NoError(t, db.GetContext(ctx, &alignments, "SELECT COUNT(*) FROM liftover_alignment"))
+	assert.Equal(t, 1, alignments)
+
+	t.Run("Down", func(t *testing.T) {
+		require.NoError(t, goose.DownToContext(ctx, db.DB, "migrations", 20240105291342))
+
+		var count int
+		require.NoError(t, db.GetContext(ctx, &count, "SELECT COUNT(*) FROM pragma_table_info('liftover_chain') WHERE name = 'query_ref'"))
+		assert.Zero(t, count)
+
+		require.NoError(t, goose.DownToContext(ctx, db.DB, "migrations", 0))
+		require.NoError(t, goose.UpContext(ctx, db.DB, "migrations"))
+	})
+
+	require.NoError(t, db.Close())
+
+	gdb, err := genobase.Open(ctx, slogt.New(t), path)
+	require.NoError(t, err)
+	require.NoError(t, gdb.Close())
+}
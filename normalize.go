@@ -0,0 +1,158 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zymatik-com/genobase/types"
+)
+
+// The number of bases to fetch at a time when left-aligning.
+const normalizeWindow = 64
+
+// NormalizeVariant left-aligns and trims the reference and alternate alleles
+// of a variant (equivalent to bcftools norm), returning the normalized
+// position and alleles. The alleles of multiallelic variants are normalized
+// together, so that they continue to share a reference allele.
+//
+// Variants with symbolic alleles (eg. "<DEL>", "*"), or whose alternate allele
+// is the same as the reference, are returned as is.
+func NormalizeVariant(ctx context.Context, seq SequenceSource, reference types.Reference, chromosome types.Chromosome,
+	position int64, ref string, alts ...string) (int64, string, []string, error) {
+	if len(alts) == 0 {
+		return position, ref, alts, nil
+	}
+
+	alleles := make([]string, 0, len(alts)+1)
+	for _, allele := range append([]string{ref}, alts...) {
+		allele = strings.ToUpper(allele)
+		if allele == "" || strings.Trim(allele, "ACGTN") != "" {
+			return position, ref, alts, nil
+		}
+
+		if len(alleles) > 0 && allele == alleles[0] {
+			return position, ref, alts, nil
+		}
+
+		alleles = append(alleles, allele)
+	}
+
+	// The bases preceding the current position, fetched as needed.
+	var preceding string
+
+	for {
+		changed := false
+
+		// Trim the common last base.
+		if allNonEmpty(alleles) && sameBase(alleles, func(allele string) byte { return allele[len(allele)-1] }) {
+			for i := range alleles {
+				alleles[i] = alleles[i][:len(alleles[i])-1]
+			}
+			changed = true
+		}
+
+		// Extend to the left, so that no allele is empty.
+		if !allNonEmpty(alleles) {
+			if position <= 1 {
+				return 0, "", nil, fmt.Errorf("could not left-align variant past the start of chromosome %s", chromosome)
+			}
+
+			if preceding == "" {
+				start := max(0, position-1-normalizeWindow)
+
+				var err error
+				preceding, err = seq.GetSequence(ctx, reference, chromosome, start, position-1)
+				if err != nil {
+					return 0, "", nil, fmt.Errorf("could not get reference sequence: %w", err)
+				}
+
+				if int64(len(preceding)) != position-1-start {
+					return 0, "", nil, fmt.Errorf("reference sequence is truncated")
+				}
+			}
+
+			base := preceding[len(preceding)-1:]
+			preceding = preceding[:len(preceding)-1]
+
+			for i := range alleles {
+				alleles[i] = base + alleles[i]
+			}
+			position--
+			changed = true
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	// Trim the common leading bases, leaving at least one base in each allele.
+	for minLength(alleles) >= 2 && sameBase(alleles, func(allele string) byte { return allele[0] }) {
+		for i := range alleles {
+			alleles[i] = alleles[i][1:]
+		}
+		position++
+	}
+
+	return position, alleles[0], alleles[1:], nil
+}
+
+// normalizeVariant normalizes a variant on a reference genome assembly (see
+// assemblySequence). Variants on chromosomes without a sequence are returned
+// as is.
+func (db *DB) normalizeVariant(ctx context.Context, reference types.Reference, chromosome types.Chromosome,
+	position int64, ref string, alts ...string) (int64, string, []string, error) {
+	seq := db.assemblySequence(reference)
+	if seq == nil {
+		return position, ref, alts, nil
+	}
+
+	normalizedPosition, normalizedRef, normalizedAlts, err := NormalizeVariant(ctx, seq, reference, chromosome, position, ref, alts...)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return position, ref, alts, nil
+		}
+
+		return 0, "", nil, err
+	}
+
+	return normalizedPosition, normalizedRef, normalizedAlts, nil
+}
+
+func allNonEmpty(alleles []string) bool {
+	return minLength(alleles) > 0
+}
+
+func minLength(alleles []string) int {
+	length := len(alleles[0])
+	for _, allele := range alleles[1:] {
+		length = min(length, len(allele))
+	}
+
+	return length
+}
+
+func sameBase(alleles []string, base func(allele string) byte) bool {
+	for _, allele := range alleles[1:] {
+		if base(allele) != base(alleles[0]) {
+			return false
+		}
+	}
+
+	return true
+}
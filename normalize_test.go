@@ -0,0 +1,120 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestNormalizeVariant(t *testing.T) {
+	ctx := context.Background()
+
+	// A (CA)n repeat on chromosome 1, starting at position 101.
+	seq := fakeSequence{}
+	for i, base := range []byte("TCACACAG") {
+		seq[types.Locus{Chromosome: types.Chr1, Position: int64(101 + i)}] = base
+	}
+
+	normalize := func(t *testing.T, position int64, ref string, alts ...string) (int64, string, []string) {
+		position, ref, alts, err := genobase.NormalizeVariant(ctx, seq, types.ReferenceGRCh38, types.Chr1, position, ref, alts...)
+		require.NoError(t, err)
+
+		return position, ref, alts
+	}
+
+	t.Run("Normalized", func(t *testing.T) {
+		position, ref, alts := normalize(t, 102, "C", "G")
+		assert.Equal(t, int64(102), position)
+		assert.Equal(t, "C", ref)
+		assert.Equal(t, []string{"G"}, alts)
+	})
+
+	t.Run("LeftAlign", func(t *testing.T) {
+		position, ref, alts := normalize(t, 105, "ACA", "A")
+		assert.Equal(t, int64(101), position)
+		assert.Equal(t, "TCA", ref)
+		assert.Equal(t, []string{"T"}, alts)
+
+		position, ref, alts = normalize(t, 107, "A", "ACA")
+		assert.Equal(t, int64(101), position)
+		assert.Equal(t, "T", ref)
+		assert.Equal(t, []string{"TCA"}, alts)
+	})
+
+	t.Run("Trim", func(t *testing.T) {
+		position, ref, alts := normalize(t, 101, "TCAC", "TGAC")
+		assert.Equal(t, int64(102), position)
+		assert.Equal(t, "C", ref)
+		assert.Equal(t, []string{"G"}, alts)
+	})
+
+	t.Run("Multiallelic", func(t *testing.T) {
+		position, ref, alts := normalize(t, 105, "ACA", "A", "AGA")
+		assert.Equal(t, int64(104), position)
+		assert.Equal(t, "CAC", ref)
+		assert.Equal(t, []string{"C", "CAG"}, alts)
+	})
+
+	t.Run("Symbolic", func(t *testing.T) {
+		position, ref, alts := normalize(t, 105, "ACA", "<DEL>")
+		assert.Equal(t, int64(105), position)
+		assert.Equal(t, "ACA", ref)
+		assert.Equal(t, []string{"<DEL>"}, alts)
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		db, err := genobase.Open(ctx, slogt.New(t), "")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, db.Close())
+		})
+
+		require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+			{ID: 1, Chromosome: types.Chr1, Position: 101, Class: types.VariantClassDEL},
+		}))
+
+		require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+			{ID: 1, Reference: "TCA", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+		}))
+
+		db.SetSequenceSource(seq)
+
+		allele, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, types.Chr1, 105, "ACA", "A", types.AncestryGroupAll)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), allele.ID)
+
+		// Not left-aligned.
+		allele, err = db.GetAllele(ctx, 1, "ACA", "A", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, "TCA", allele.Reference)
+		assert.Equal(t, "T", allele.Alternate)
+
+		// Padded.
+		allele, err = db.GetAllele(ctx, 1, "TCAC", "TC", types.AncestryGroupAll)
+		require.NoError(t, err)
+		assert.Equal(t, "TCA", allele.Reference)
+
+		// A different deletion.
+		_, err = db.GetAllele(ctx, 1, "ACAG", "A", types.AncestryGroupAll)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
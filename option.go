@@ -12,7 +12,11 @@
 
 package genobase
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/zymatik-com/genobase/types"
+)
 
 type Option func(string) string
 
@@ -38,3 +42,47 @@ func NoSync(connStr string) string {
 
 	return connStr + "_journal_mode=OFF&_synchronous=OFF"
 }
+
+// The default number of records to store per transaction when importing.
+const DefaultImportBatchSize = 10000
+
+type importOptions struct {
+	batchSize int
+	assembly  types.Reference
+}
+
+// ImportOption configures bulk imports.
+type ImportOption func(*importOptions)
+
+// BatchSize sets the number of records to store per transaction.
+func BatchSize(n int) ImportOption {
+	return func(opts *importOptions) {
+		opts.batchSize = n
+	}
+}
+
+// Assembly sets the reference genome assembly of an imported dbSNP file
+// (GRCh38 by default). Variant positions on other assemblies are stored
+// alongside the GRCh38 positions (see StoreVariantPositions). Assemblies
+// other than GRCh38 are only supported by ImportDbSNP.
+func Assembly(reference types.Reference) ImportOption {
+	return func(opts *importOptions) {
+		opts.assembly = reference
+	}
+}
+
+func newImportOptions(opts ...ImportOption) importOptions {
+	options := importOptions{
+		batchSize: DefaultImportBatchSize,
+		assembly:  types.ReferenceGRCh38,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.batchSize <= 0 {
+		options.batchSize = DefaultImportBatchSize
+	}
+
+	return options
+}
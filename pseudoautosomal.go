@@ -0,0 +1,113 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"strings"
+
+	"github.com/zymatik-com/genobase/types"
+)
+
+// pseudoautosomalLocus returns the equivalent position within a pseudoautosomal
+// region (ChrPAR or ChrPAR2) of a 1-based position on chromosome X or Y, so
+// that variants stored on the pseudoautosomal regions can be found by their
+// sex chromosome positions.
+func pseudoautosomalLocus(reference types.Reference, chromosome types.Chromosome, position int64) (*types.Locus, bool) {
+	// Positions that can't be mapped (eg. on unsupported assemblies) can only
+	// be stored on the sex chromosome itself.
+	locus, ok, err := types.ToPseudoautosomal(reference, chromosome, position)
+	return locus, ok && err == nil
+}
+
+// positionCondition returns an SQL condition (and its arguments) matching a
+// position on a chromosome, or the equivalent position within a
+// pseudoautosomal region.
+func positionCondition(reference types.Reference, table string, chromosome types.Chromosome, position int64) (string, []any) {
+	condition := "(" + table + ".chromosome = ? AND " + table + ".position = ?)"
+	args := []any{chromosome, position}
+
+	if par, ok := pseudoautosomalLocus(reference, chromosome, position); ok {
+		condition = "(" + condition + " OR (" + table + ".chromosome = ? AND " + table + ".position = ?))"
+		args = append(args, par.Chromosome, par.Position)
+	}
+
+	return condition, args
+}
+
+// variantRange is a range (start, end] of 1-based variant positions on a
+// chromosome. Offset converts positions within the range into the coordinates
+// of the chromosome that was originally requested.
+type variantRange struct {
+	chromosome types.Chromosome
+	start, end int64
+	offset     int64
+}
+
+// variantRanges splits the 0-based, half-open interval [start, end) on a
+// chromosome (on GRCh38) into consecutive segments. Segments that overlap
+// a pseudoautosomal region also include the equivalent range on ChrPAR/ChrPAR2.
+func variantRanges(chromosome types.Chromosome, start, end int64) [][]variantRange {
+	whole := [][]variantRange{{{chromosome: chromosome, start: start, end: end}}}
+	if chromosome != types.ChrX && chromosome != types.ChrY {
+		return whole
+	}
+
+	regions, err := types.PseudoautosomalRegions(types.ReferenceGRCh38)
+	if err != nil {
+		return whole
+	}
+
+	var segments [][]variantRange
+	cursor := start
+	for _, region := range regions {
+		bounds := region.X
+		if chromosome == types.ChrY {
+			bounds = region.Y
+		}
+
+		if bounds.End <= cursor || bounds.Start >= end {
+			continue
+		}
+
+		if cursor < bounds.Start {
+			segments = append(segments, []variantRange{{chromosome: chromosome, start: cursor, end: bounds.Start}})
+		}
+
+		segmentStart, segmentEnd := max(cursor, bounds.Start), min(end, bounds.End)
+		segments = append(segments, []variantRange{
+			{chromosome: chromosome, start: segmentStart, end: segmentEnd},
+			{chromosome: region.Chromosome, start: segmentStart - bounds.Start, end: segmentEnd - bounds.Start, offset: bounds.Start},
+		})
+
+		cursor = segmentEnd
+	}
+
+	if cursor < end || len(segments) == 0 {
+		segments = append(segments, []variantRange{{chromosome: chromosome, start: cursor, end: end}})
+	}
+
+	return segments
+}
+
+// rangeCondition returns an SQL condition (and its arguments) matching any of
+// the variant ranges in a segment.
+func rangeCondition(segment []variantRange) (string, []any) {
+	var conditions []string
+	var args []any
+	for _, r := range segment {
+		conditions = append(conditions, "(chromosome = ? AND position > ? AND position <= ?)")
+		args = append(args, r.chromosome, r.start, r.end)
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
@@ -0,0 +1,193 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestPseudoautosomal(t *testing.T) {
+	t.Run("Regions", func(t *testing.T) {
+		ctx := context.Background()
+
+		db, err := genobase.Open(ctx, slogt.New(t), "")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, db.Close())
+		})
+
+		for _, reference := range []types.Reference{
+			types.ReferenceNCBI36, types.ReferenceGRCh37, types.ReferenceGRCh38, types.ReferenceTelomereToTelomereV2,
+		} {
+			regions, err := types.PseudoautosomalRegions(reference)
+			require.NoError(t, err)
+			require.Len(t, regions, 2)
+
+			xLength, err := db.GetChromosomeLength(ctx, reference, types.ChrX)
+			require.NoError(t, err)
+
+			yLength, err := db.GetChromosomeLength(ctx, reference, types.ChrY)
+			require.NoError(t, err)
+
+			for _, region := range regions {
+				length, err := db.GetChromosomeLength(ctx, reference, region.Chromosome)
+				require.NoError(t, err)
+
+				assert.Equal(t, length, region.Length(), "%s %s", reference, region.Chromosome)
+				assert.LessOrEqual(t, region.X.End, xLength)
+				assert.LessOrEqual(t, region.Y.End, yLength)
+			}
+		}
+
+		_, err = types.PseudoautosomalRegions("hg16")
+		assert.Error(t, err)
+	})
+
+	t.Run("Mapping", func(t *testing.T) {
+		locus, ok, err := types.ToPseudoautosomal(types.ReferenceGRCh38, types.ChrX, 10001)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, types.Locus{Chromosome: types.ChrPAR, Position: 1}, *locus)
+
+		locus, ok, err = types.ToPseudoautosomal(types.ReferenceGRCh38, types.ChrY, 57217415)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, types.Locus{Chromosome: types.ChrPAR2, Position: 329513}, *locus)
+
+		// GRCh37 PAR1 starts at a different position on X and Y.
+		locus, ok, err = types.ToPseudoautosomal(types.ReferenceGRCh37, types.ChrX, 60001)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, types.Locus{Chromosome: types.ChrPAR, Position: 1}, *locus)
+
+		position, err := types.FromPseudoautosomal(types.ReferenceGRCh37, types.ChrPAR, 1, types.ChrY)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10001), position)
+
+		for _, tc := range []struct {
+			chromosome types.Chromosome
+			position   int64
+		}{
+			{types.ChrX, 10000},
+			{types.ChrX, 2781480},
+			{types.ChrY, 30000000},
+			{types.Chr1, 20000},
+		} {
+			_, ok, err := types.ToPseudoautosomal(types.ReferenceGRCh38, tc.chromosome, tc.position)
+			require.NoError(t, err)
+			assert.False(t, ok, "%s:%d", tc.chromosome, tc.position)
+		}
+
+		for _, chromosome := range []types.Chromosome{types.ChrX, types.ChrY} {
+			for _, position := range []int64{1, 12345, 329513} {
+				mapped, err := types.FromPseudoautosomal(types.ReferenceGRCh38, types.ChrPAR2, position, chromosome)
+				require.NoError(t, err)
+
+				locus, ok, err := types.ToPseudoautosomal(types.ReferenceGRCh38, chromosome, mapped)
+				require.NoError(t, err)
+				require.True(t, ok)
+				assert.Equal(t, types.Locus{Chromosome: types.ChrPAR2, Position: position}, *locus)
+			}
+		}
+
+		_, err = types.FromPseudoautosomal(types.ReferenceGRCh38, types.ChrPAR2, 329514, types.ChrX)
+		assert.Error(t, err)
+
+		_, err = types.FromPseudoautosomal(types.ReferenceGRCh38, types.ChrPAR, 1, types.Chr1)
+		assert.Error(t, err)
+
+		_, err = types.FromPseudoautosomal(types.ReferenceGRCh38, types.Chr1, 1, types.ChrX)
+		assert.Error(t, err)
+
+		// The T2T-CHM13v2.0 pseudoautosomal regions differ in length between X and Y.
+		_, _, err = types.ToPseudoautosomal(types.ReferenceTelomereToTelomereV2, types.ChrY, 100)
+		assert.Error(t, err)
+
+		_, ok, err = types.ToPseudoautosomal(types.ReferenceTelomereToTelomereV2, types.ChrY, 30000000)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		locus, ok, err = types.ToPseudoautosomal(types.ReferenceTelomereToTelomereV2, types.ChrX, 100)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, types.Locus{Chromosome: types.ChrPAR, Position: 100}, *locus)
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		ctx := context.Background()
+
+		db, err := genobase.Open(ctx, slogt.New(t), "")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, db.Close())
+		})
+
+		require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+			{ID: 1, Chromosome: types.ChrX, Position: 9990, Class: types.VariantClassSNV},
+			{ID: 2, Chromosome: types.ChrPAR, Position: 100, Class: types.VariantClassSNV},
+			{ID: 3, Chromosome: types.ChrX, Position: 10150, Class: types.VariantClassSNV},
+			{ID: 4, Chromosome: types.ChrPAR, Position: 200, Class: types.VariantClassSNV},
+			{ID: 5, Chromosome: types.ChrY, Position: 2781480, Class: types.VariantClassSNV},
+		}))
+
+		require.NoError(t, db.StoreAlleles(ctx, []types.Allele{
+			{ID: 2, Reference: "G", Alternate: "A", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+		}))
+
+		for _, chromosome := range []types.Chromosome{types.ChrX, types.ChrY} {
+			variants, err := db.GetVariants(ctx, chromosome, 10100)
+			require.NoError(t, err)
+			require.Len(t, variants, 1)
+			assert.Equal(t, int64(2), variants[0].ID)
+
+			allele, err := db.GetAlleleByPosition(ctx, types.ReferenceGRCh38, chromosome, 10100, "G", "A", types.AncestryGroupAll)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), allele.ID)
+		}
+
+		variants, err := db.GetVariants(ctx, types.ChrPAR, 100)
+		require.NoError(t, err)
+		require.Len(t, variants, 1)
+
+		// Variants are ordered by their position on the sex chromosome.
+		variants, err = db.GetVariantsInRange(ctx, types.ChrX, 9000, 20000)
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, variant := range variants {
+			ids = append(ids, variant.ID)
+		}
+		assert.Equal(t, []int64{1, 2, 3, 4}, ids)
+
+		variants, err = db.GetVariantsInRange(ctx, types.ChrY, 0, 2781480)
+		require.NoError(t, err)
+
+		ids = nil
+		for _, variant := range variants {
+			ids = append(ids, variant.ID)
+		}
+		assert.Equal(t, []int64{2, 4, 5}, ids)
+
+		variants, err = db.GetVariantsInRange(ctx, types.ChrX, 10150, 10250, types.VariantClassSNV)
+		require.NoError(t, err)
+		require.Len(t, variants, 1)
+		assert.Equal(t, int64(4), variants[0].ID)
+	})
+}
@@ -0,0 +1,348 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zymatik-com/genobase/fasta"
+	"github.com/zymatik-com/genobase/types"
+)
+
+// The number of bases stored per chunk.
+const sequenceChunkSize = 1 << 16
+
+// The 4-bit codes of the IUPAC nucleotide symbols (as used in BAM files).
+const packedBases = "=ACMGRSVTWYHKDBN"
+
+var baseCodes = func() (codes [256]byte) {
+	for i := range codes {
+		codes[i] = 0xff
+	}
+
+	for code, base := range []byte(packedBases) {
+		codes[base] = byte(code)
+		codes[base|0x20] = byte(code) // Lowercase (soft masked) bases.
+	}
+
+	return codes
+}()
+
+// SequenceSource provides access to the sequence of a reference genome assembly.
+type SequenceSource interface {
+	// GetSequence returns the (uppercase) sequence of the 0-based, half-open
+	// interval [start, end) on a chromosome.
+	GetSequence(ctx context.Context, reference types.Reference, chromosome types.Chromosome, start, end int64) (string, error)
+}
+
+var _ SequenceSource = (*DB)(nil)
+
+// SetSequenceSource sets the source of reference genome sequence. The GRCh38
+// sequence is used to normalize (left-align and trim) variants when importing
+// and looking up alleles by position, and the sequence of the target assembly
+// is used to check lifted variants (see LiftoverVariant).
+//
+// Once the GRCh38 sequence has been imported with ImportSequence, the database
+// itself is used by default. If there is no sequence source, GRCh38 variants
+// are not normalized. Variants on chromosomes without a sequence are never
+// normalized, so that imports and lookups remain consistent. The imported
+// sequence of other assemblies is always used, when the sequence source has
+// none.
+func (db *DB) SetSequenceSource(seq SequenceSource) {
+	db.sequenceMu.Lock()
+	defer db.sequenceMu.Unlock()
+
+	db.sequence = seq
+}
+
+// assemblySequence returns the sequence to normalize variants on a reference
+// genome assembly against. GRCh38 variants are only normalized if there is a
+// sequence source, other assemblies use their reference sequence.
+func (db *DB) assemblySequence(reference types.Reference) SequenceSource {
+	if reference == types.ReferenceGRCh38 {
+		return db.sequenceSource()
+	}
+
+	return db.referenceSequence()
+}
+
+// referenceSequence returns the sequence of any reference genome assembly:
+// from the sequence source (if set), falling back to the imported sequence.
+func (db *DB) referenceSequence() SequenceSource {
+	seq := db.sequenceSource()
+	if seq == nil || seq == db {
+		return db
+	}
+
+	return &fallbackSequence{primary: seq, fallback: db}
+}
+
+// fallbackSequence reads sequence from one source, falling back to another
+// for chromosomes the first has no sequence for.
+type fallbackSequence struct {
+	primary, fallback SequenceSource
+}
+
+func (s *fallbackSequence) GetSequence(ctx context.Context, reference types.Reference, chromosome types.Chromosome, start, end int64) (string, error) {
+	sequence, err := s.primary.GetSequence(ctx, reference, chromosome, start, end)
+	if errors.Is(err, os.ErrNotExist) {
+		return s.fallback.GetSequence(ctx, reference, chromosome, start, end)
+	}
+
+	return sequence, err
+}
+
+// sequenceSource returns the source of reference genome sequence, or nil if
+// there is none.
+func (db *DB) sequenceSource() SequenceSource {
+	db.sequenceMu.RLock()
+	defer db.sequenceMu.RUnlock()
+
+	return db.sequence
+}
+
+// ImportSequence imports the sequence of a reference genome assembly from a
+// FASTA file (eg. GCA_000001405.15_GRCh38_no_alt_analysis_set.fna.gz). The
+// file may be gzip compressed. Sequences of alternate contigs, unplaced
+// scaffolds etc. are skipped. Previously imported chromosomes are replaced,
+// and the chromosome lengths are updated.
+func (db *DB) ImportSequence(ctx context.Context, reference types.Reference, r io.Reader) error {
+	fr, err := fasta.NewReader(r, sequenceChunkSize)
+	if err != nil {
+		return fmt.Errorf("could not open fasta file: %w", err)
+	}
+
+	tx, err := db.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	chunkStmt, err := tx.PreparexContext(ctx, "INSERT INTO sequence_chunk (ref, chromosome, chunk, bases) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("could not prepare statement: %w", err)
+	}
+	defer chunkStmt.Close()
+
+	var name string
+	var chromosome types.Chromosome
+	var length int64
+	var imported, skipped int
+
+	finish := func() error {
+		if chromosome == "" {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO sequence (ref, chromosome, length, chunk_size) VALUES (?, ?, ?, ?)",
+			reference, chromosome, length, sequenceChunkSize); err != nil {
+			return fmt.Errorf("could not store sequence: %w", err)
+		}
+
+		if err := storeChromosomeLength(ctx, tx, reference, chromosome, length); err != nil {
+			return err
+		}
+
+		db.logger.Info("Imported sequence", "reference", reference, "chromosome", chromosome, "length", length)
+
+		imported++
+		chromosome, length = "", 0
+
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		chunk, err := fr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return fmt.Errorf("could not read sequence: %w", err)
+		}
+
+		if chunk.Name != name {
+			if err := finish(); err != nil {
+				return err
+			}
+
+			name = chunk.Name
+
+			chromosome, err = types.ParseChromosome(name, reference)
+			if err != nil {
+				skipped++
+				continue
+			}
+
+			for _, table := range []string{"sequence_chunk", "sequence"} {
+				if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE ref = ? AND chromosome = ?", reference, chromosome); err != nil {
+					return fmt.Errorf("could not delete existing sequence: %w", err)
+				}
+			}
+		}
+
+		if chromosome == "" {
+			continue
+		}
+
+		packed, err := packBases(chunk.Bases)
+		if err != nil {
+			return fmt.Errorf("could not pack %s:%d: %w", name, chunk.Offset, err)
+		}
+
+		if _, err := chunkStmt.ExecContext(ctx, reference, chromosome, chunk.Offset/sequenceChunkSize, packed); err != nil {
+			return fmt.Errorf("could not store sequence chunk: %w", err)
+		}
+
+		length += int64(len(chunk.Bases))
+	}
+
+	if err := finish(); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	db.logger.Info("Imported sequences", "imported", imported, "skipped", skipped)
+
+	if reference == types.ReferenceGRCh38 {
+		db.sequenceMu.Lock()
+		if db.sequence == nil {
+			db.sequence = db
+		}
+		db.sequenceMu.Unlock()
+	}
+
+	return nil
+}
+
+// GetSequence returns the (uppercase) sequence of the 0-based, half-open
+// interval [start, end) on a chromosome, from the imported reference sequence.
+func (db *DB) GetSequence(ctx context.Context, reference types.Reference, chromosome types.Chromosome, start, end int64) (string, error) {
+	var sequence struct {
+		Length    int64 `db:"length"`
+		ChunkSize int64 `db:"chunk_size"`
+	}
+	if err := db.db.GetContext(ctx, &sequence, "SELECT length, chunk_size FROM sequence WHERE ref = ? AND chromosome = ?",
+		reference, chromosome); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("no sequence for %s chromosome %s: %w", reference, chromosome, os.ErrNotExist)
+		}
+
+		return "", fmt.Errorf("could not query sequence: %w", err)
+	}
+
+	if start < 0 || end < start || end > sequence.Length {
+		return "", fmt.Errorf("interval [%d, %d) is out of range for chromosome %s (length %d)", start, end, chromosome, sequence.Length)
+	}
+
+	if start == end {
+		return "", nil
+	}
+
+	rows, err := db.db.QueryxContext(ctx, `SELECT chunk, bases FROM sequence_chunk 
+		WHERE ref = ? AND chromosome = ? AND chunk BETWEEN ? AND ? ORDER BY chunk`,
+		reference, chromosome, start/sequence.ChunkSize, (end-1)/sequence.ChunkSize)
+	if err != nil {
+		return "", fmt.Errorf("could not query sequence chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	sb.Grow(int(end - start))
+
+	next := start / sequence.ChunkSize
+	for rows.Next() {
+		var index int64
+		var packed []byte
+		if err := rows.Scan(&index, &packed); err != nil {
+			return "", fmt.Errorf("could not scan sequence chunk: %w", err)
+		}
+
+		if index != next {
+			return "", fmt.Errorf("missing sequence chunk %d", next)
+		}
+		next++
+
+		// The offsets within the chunk of the requested bases.
+		chunkStart := index * sequence.ChunkSize
+		from := max(start, chunkStart) - chunkStart
+		to := min(end, chunkStart+sequence.ChunkSize) - chunkStart
+
+		if err := unpackBases(&sb, packed, from, to); err != nil {
+			return "", err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("could not scan sequence chunks: %w", err)
+	}
+
+	if int64(sb.Len()) != end-start {
+		return "", fmt.Errorf("sequence is truncated")
+	}
+
+	return sb.String(), nil
+}
+
+// packBases packs bases two per byte using 4-bit codes, the first base of each
+// pair is stored in the high nibble.
+func packBases(bases []byte) ([]byte, error) {
+	packed := make([]byte, (len(bases)+1)/2)
+	for i, base := range bases {
+		code := baseCodes[base]
+		if code == 0xff {
+			return nil, fmt.Errorf("invalid base %q", base)
+		}
+
+		if i%2 == 0 {
+			packed[i/2] = code << 4
+		} else {
+			packed[i/2] |= code
+		}
+	}
+
+	return packed, nil
+}
+
+// unpackBases unpacks the bases in the interval [from, to) of a packed chunk.
+func unpackBases(sb *strings.Builder, packed []byte, from, to int64) error {
+	if to > int64(len(packed))*2 {
+		return fmt.Errorf("sequence chunk is truncated")
+	}
+
+	for i := from; i < to; i++ {
+		code := packed[i/2]
+		if i%2 == 0 {
+			code >>= 4
+		}
+
+		sb.WriteByte(packedBases[code&0x0f])
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestSequence(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	f, err := os.Open("fasta/testdata/example.fa")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+
+	require.NoError(t, db.ImportSequence(ctx, types.ReferenceGRCh38, f))
+
+	t.Run("GetSequence", func(t *testing.T) {
+		seq, err := db.GetSequence(ctx, types.ReferenceGRCh38, types.Chr1, 0, 22)
+		require.NoError(t, err)
+		assert.Equal(t, "NNNNACGTACGTACGTRYKMAC", seq)
+
+		seq, err = db.GetSequence(ctx, types.ReferenceGRCh38, types.Chr1, 5, 8)
+		require.NoError(t, err)
+		assert.Equal(t, "CGT", seq)
+
+		seq, err = db.GetSequence(ctx, types.ReferenceGRCh38, types.Chr2, 4, 12)
+		require.NoError(t, err)
+		assert.Equal(t, "GGGGCCCC", seq)
+
+		_, err = db.GetSequence(ctx, types.ReferenceGRCh38, types.Chr1, 20, 23)
+		assert.Error(t, err)
+
+		_, err = db.GetSequence(ctx, types.ReferenceGRCh37, types.Chr1, 0, 1)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Chunks", func(t *testing.T) {
+		// Long enough to span several chunks.
+		sequence := strings.Repeat("ACGTTGCAN", 50_000)
+
+		var fa bytes.Buffer
+		fa.WriteString(">chr3\n")
+		for i := 0; i < len(sequence); i += 60 {
+			fa.WriteString(sequence[i:min(i+60, len(sequence))] + "\n")
+		}
+
+		require.NoError(t, db.ImportSequence(ctx, types.ReferenceGRCh38, &fa))
+
+		for _, interval := range [][2]int64{{0, 1}, {65530, 65540}, {65536, 65537}, {100_000, 300_000}, {449_990, 450_000}} {
+			seq, err := db.GetSequence(ctx, types.ReferenceGRCh38, types.Chr3, interval[0], interval[1])
+			require.NoError(t, err)
+
+			assert.Equal(t, sequence[interval[0]:interval[1]], seq)
+		}
+
+		// Other chromosomes are untouched.
+		seq, err := db.GetSequence(ctx, types.ReferenceGRCh38, types.Chr1, 0, 4)
+		require.NoError(t, err)
+		assert.Equal(t, "NNNN", seq)
+	})
+}
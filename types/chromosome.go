@@ -62,70 +62,146 @@ func (c Chromosome) Less(comparison Chromosome) bool {
 	return c.int() < comparison.int()
 }
 
-// Length returns the length of the chromosome in base pairs.
+// Length returns the length of the chromosome in base pairs on a reference
+// genome assembly. The lengths of the pseudoautosomal regions are those on
+// chromosome X. It panics if the assembly or chromosome is unknown.
+//
+// Deprecated: Use DB.GetChromosomeLength, which also knows the lengths of
+// assemblies imported from a FASTA index.
 func (c Chromosome) Length(reference Reference) int64 {
-	if reference != ReferenceGRCh38 {
-		panic("Only GRCh38 is supported")
+	lengths, ok := chromosomeLengths[reference]
+	if !ok {
+		panic("Unsupported reference genome assembly")
 	}
 
-	switch c {
-	case Chr1:
-		return 248956422
-	case Chr2:
-		return 242193529
-	case Chr3:
-		return 198295559
-	case Chr4:
-		return 190214555
-	case Chr5:
-		return 181538259
-	case Chr6:
-		return 170805979
-	case Chr7:
-		return 159345973
-	case Chr8:
-		return 145138636
-	case Chr9:
-		return 138394717
-	case Chr10:
-		return 133797422
-	case Chr11:
-		return 135086622
-	case Chr12:
-		return 133275309
-	case Chr13:
-		return 114364328
-	case Chr14:
-		return 107043718
-	case Chr15:
-		return 101991189
-	case Chr16:
-		return 90338345
-	case Chr17:
-		return 83257441
-	case Chr18:
-		return 80373285
-	case Chr19:
-		return 58617616
-	case Chr20:
-		return 64444167
-	case Chr21:
-		return 46709983
-	case Chr22:
-		return 50818468
-	case ChrX:
-		return 156040895
-	case ChrY:
-		return 57227415
-	case ChrMT:
-		return 16569
-	case ChrPAR:
-		return 2771479
-	case ChrPAR2:
-		return 329513
-	default:
+	length, ok := lengths[c]
+	if !ok {
 		panic("Unknown chromosome")
 	}
+
+	return length
+}
+
+// The lengths of each chromosome on the supported reference genome assemblies.
+// The chromosome_length migration was generated from this table, so the two
+// must be kept in sync (TestChromosomeLength checks they match).
+var chromosomeLengths = map[Reference]map[Chromosome]int64{
+	ReferenceNCBI36: {
+		Chr1:    247249719,
+		Chr2:    242951149,
+		Chr3:    199501827,
+		Chr4:    191273063,
+		Chr5:    180857866,
+		Chr6:    170899992,
+		Chr7:    158821424,
+		Chr8:    146274826,
+		Chr9:    140273252,
+		Chr10:   135374737,
+		Chr11:   134452384,
+		Chr12:   132349534,
+		Chr13:   114142980,
+		Chr14:   106368585,
+		Chr15:   100338915,
+		Chr16:   88827254,
+		Chr17:   78774742,
+		Chr18:   76117153,
+		Chr19:   63811651,
+		Chr20:   62435964,
+		Chr21:   46944323,
+		Chr22:   49691432,
+		ChrX:    154913754,
+		ChrY:    57772954,
+		ChrMT:   16571, // NC_001807, rather than the rCRS.
+		ChrPAR:  2709520,
+		ChrPAR2: 329517,
+	},
+	ReferenceGRCh37: {
+		Chr1:    249250621,
+		Chr2:    243199373,
+		Chr3:    198022430,
+		Chr4:    191154276,
+		Chr5:    180915260,
+		Chr6:    171115067,
+		Chr7:    159138663,
+		Chr8:    146364022,
+		Chr9:    141213431,
+		Chr10:   135534747,
+		Chr11:   135006516,
+		Chr12:   133851895,
+		Chr13:   115169878,
+		Chr14:   107349540,
+		Chr15:   102531392,
+		Chr16:   90354753,
+		Chr17:   81195210,
+		Chr18:   78077248,
+		Chr19:   59128983,
+		Chr20:   63025520,
+		Chr21:   48129895,
+		Chr22:   51304566,
+		ChrX:    155270560,
+		ChrY:    59373566,
+		ChrMT:   16569,
+		ChrPAR:  2639520,
+		ChrPAR2: 329517,
+	},
+	ReferenceGRCh38: {
+		Chr1:    248956422,
+		Chr2:    242193529,
+		Chr3:    198295559,
+		Chr4:    190214555,
+		Chr5:    181538259,
+		Chr6:    170805979,
+		Chr7:    159345973,
+		Chr8:    145138636,
+		Chr9:    138394717,
+		Chr10:   133797422,
+		Chr11:   135086622,
+		Chr12:   133275309,
+		Chr13:   114364328,
+		Chr14:   107043718,
+		Chr15:   101991189,
+		Chr16:   90338345,
+		Chr17:   83257441,
+		Chr18:   80373285,
+		Chr19:   58617616,
+		Chr20:   64444167,
+		Chr21:   46709983,
+		Chr22:   50818468,
+		ChrX:    156040895,
+		ChrY:    57227415,
+		ChrMT:   16569,
+		ChrPAR:  2771479,
+		ChrPAR2: 329513,
+	},
+	ReferenceTelomereToTelomereV2: {
+		Chr1:    248387328,
+		Chr2:    242696752,
+		Chr3:    201105948,
+		Chr4:    193574945,
+		Chr5:    182045439,
+		Chr6:    172126628,
+		Chr7:    160567428,
+		Chr8:    146259331,
+		Chr9:    150617247,
+		Chr10:   134758134,
+		Chr11:   135127769,
+		Chr12:   133324548,
+		Chr13:   113566686,
+		Chr14:   101161492,
+		Chr15:   99753195,
+		Chr16:   96330374,
+		Chr17:   84276897,
+		Chr18:   80542538,
+		Chr19:   61707364,
+		Chr20:   66210255,
+		Chr21:   45090682,
+		Chr22:   51324926,
+		ChrX:    154259566,
+		ChrY:    62460029,
+		ChrMT:   16569,
+		ChrPAR:  2394410,
+		ChrPAR2: 333732,
+	},
 }
 
 func (c Chromosome) int() int {
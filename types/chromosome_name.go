@@ -0,0 +1,198 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChromosomeStyle is a chromosome naming convention.
+type ChromosomeStyle string
+
+const (
+	// ChromosomeStyleUCSC is the UCSC naming convention (eg. "chr1", "chrX", "chrM").
+	ChromosomeStyleUCSC ChromosomeStyle = "UCSC"
+	// ChromosomeStyleEnsembl is the Ensembl naming convention (eg. "1", "X", "MT").
+	ChromosomeStyleEnsembl ChromosomeStyle = "Ensembl"
+	// ChromosomeStyleRefSeq is the RefSeq accession of the chromosome (eg. "NC_000001.11").
+	ChromosomeStyleRefSeq ChromosomeStyle = "RefSeq"
+	// ChromosomeStylePLINK is the PLINK numeric chromosome code (eg. "1", "23", "26").
+	ChromosomeStylePLINK ChromosomeStyle = "PLINK"
+)
+
+// ParseChromosome converts a chromosome name in any of the common naming
+// conventions into a chromosome, eg. "chr1", "1", "NC_000001.11", "CM000663.2",
+// "chrM" or the PLINK codes "23" (X), "24" (Y), "25" (XY) and "26" (MT).
+//
+// The PLINK pseudoautosomal code ("XY" or "25") is returned as ChrX, as PLINK
+// positions in the pseudoautosomal regions are chromosome X positions (lookups
+// on X also find variants stored on the pseudoautosomal regions). ChrPAR and
+// ChrPAR2 are only returned for "PAR"/"PAR1" and "PAR2".
+//
+// Sequence accessions are checked against the reference genome assembly (if
+// it is known), however the accession version is ignored. Alternate contigs,
+// unplaced scaffolds etc. are not supported and will return an error.
+func ParseChromosome(name string, reference Reference) (Chromosome, error) {
+	accession, _, _ := strings.Cut(name, ".")
+	if acc, ok := accessions[strings.ToUpper(accession)]; ok {
+		if _, known := chromosomeLengths[reference]; known && !slices.Contains(acc.references, reference) {
+			return "", fmt.Errorf("sequence accession %s is not part of %s", name, reference)
+		}
+
+		return acc.chromosome, nil
+	}
+
+	trimmed := name
+	if len(trimmed) > 3 && strings.EqualFold(trimmed[:3], "chr") {
+		trimmed = trimmed[3:]
+	}
+
+	switch strings.ToUpper(trimmed) {
+	case "X", "23", "XY", "25":
+		return ChrX, nil
+	case "Y", "24":
+		return ChrY, nil
+	case "PAR", "PAR1":
+		return ChrPAR, nil
+	case "PAR2":
+		return ChrPAR2, nil
+	case "M", "MT", "26":
+		return ChrMT, nil
+	}
+
+	if num, err := strconv.Atoi(trimmed); err == nil && num >= 1 && num <= 22 && !strings.HasPrefix(trimmed, "0") {
+		return Chromosome(trimmed), nil
+	}
+
+	return "", fmt.Errorf("unsupported chromosome: %s", name)
+}
+
+// Format returns the name of the chromosome in the given naming convention.
+// RefSeq accessions are versioned and so require a reference genome assembly.
+// The pseudoautosomal regions have no name in any of the conventions, as their
+// positions are offsets into the region (see FromPseudoautosomal).
+func (c Chromosome) Format(style ChromosomeStyle, reference Reference) (string, error) {
+	if c.int() == 0 {
+		return "", fmt.Errorf("unknown chromosome: %s", c)
+	}
+
+	switch style {
+	case ChromosomeStyleUCSC:
+		switch c {
+		case ChrMT:
+			return "chrM", nil
+		case ChrPAR, ChrPAR2:
+			return "", fmt.Errorf("no UCSC name for chromosome %s", c)
+		}
+
+		return "chr" + string(c), nil
+	case ChromosomeStyleEnsembl:
+		if c == ChrPAR || c == ChrPAR2 {
+			return "", fmt.Errorf("no Ensembl name for chromosome %s", c)
+		}
+
+		return string(c), nil
+	case ChromosomeStyleRefSeq:
+		return c.refSeqAccession(reference)
+	case ChromosomeStylePLINK:
+		switch c {
+		case ChrPAR, ChrPAR2:
+			return "", fmt.Errorf("no PLINK code for chromosome %s", c)
+		case ChrMT:
+			return "26", nil
+		}
+
+		return strconv.Itoa(c.int()), nil
+	default:
+		return "", fmt.Errorf("unsupported chromosome naming convention: %s", style)
+	}
+}
+
+// refSeqAccession returns the versioned RefSeq accession of a chromosome on a
+// reference genome assembly.
+func (c Chromosome) refSeqAccession(reference Reference) (string, error) {
+	if c == ChrPAR || c == ChrPAR2 {
+		return "", fmt.Errorf("no RefSeq accession for chromosome %s", c)
+	}
+
+	if c == ChrMT && reference != ReferenceNCBI36 {
+		return refSeqMitochondrion + ".1", nil
+	}
+
+	switch reference {
+	case ReferenceGRCh37, ReferenceGRCh38:
+		return fmt.Sprintf("NC_%06d.%d", c.int(), refSeqVersions[reference][c.int()-1]), nil
+	case ReferenceTelomereToTelomereV2:
+		return fmt.Sprintf("NC_%06d.1", t2tRefSeqOffset+c.int()), nil
+	default:
+		return "", fmt.Errorf("no RefSeq accessions for reference genome assembly: %s", reference)
+	}
+}
+
+// The RefSeq accession of the mitochondrial genome (rCRS).
+const refSeqMitochondrion = "NC_012920"
+
+// The T2T-CHM13v2.0 RefSeq accessions are numbered consecutively from
+// NC_060925 (chromosome 1) to NC_060948 (chromosome Y).
+const t2tRefSeqOffset = 60924
+
+// The RefSeq accession versions of chromosomes 1-22, X and Y (NC_000001-NC_000024).
+var refSeqVersions = map[Reference][24]int{
+	ReferenceGRCh37: {10, 11, 11, 11, 9, 11, 13, 10, 11, 10, 9, 11, 10, 8, 9, 9, 10, 9, 9, 10, 8, 10, 10, 9},
+	ReferenceGRCh38: {11, 12, 12, 12, 10, 12, 14, 11, 12, 11, 10, 12, 11, 9, 10, 10, 11, 10, 10, 11, 9, 11, 11, 10},
+}
+
+type accession struct {
+	chromosome Chromosome
+	// The reference genome assemblies that include the sequence.
+	references []Reference
+}
+
+// Unversioned RefSeq and GenBank accessions of the primary assembly chromosomes.
+var accessions = func() map[string]accession {
+	accessions := map[string]accession{
+		refSeqMitochondrion: {ChrMT, []Reference{ReferenceGRCh37, ReferenceGRCh38, ReferenceTelomereToTelomereV2}},
+		// The Cambridge Reference Sequence (rCRS), as used by GenBank.
+		"J01415": {ChrMT, []Reference{ReferenceGRCh37, ReferenceGRCh38}},
+		// The mitochondrial sequence used by NCBI36 (and UCSC hg19).
+		"NC_001807": {ChrMT, []Reference{ReferenceNCBI36, ReferenceGRCh37}},
+		// T2T-CHM13v2.0 GenBank accessions that don't follow the numbering scheme.
+		"CP086569": {ChrY, []Reference{ReferenceTelomereToTelomereV2}},
+		"CP068255": {ChrX, []Reference{ReferenceTelomereToTelomereV2}},
+		"CP068254": {ChrMT, []Reference{ReferenceTelomereToTelomereV2}},
+	}
+
+	for num := 1; num <= 24; num++ {
+		chromosome := Chromosome(strconv.Itoa(num))
+		switch num {
+		case 23:
+			chromosome = ChrX
+		case 24:
+			chromosome = ChrY
+		}
+
+		accessions[fmt.Sprintf("NC_%06d", num)] = accession{chromosome, []Reference{ReferenceNCBI36, ReferenceGRCh37, ReferenceGRCh38}}
+		accessions[fmt.Sprintf("NC_%06d", t2tRefSeqOffset+num)] = accession{chromosome, []Reference{ReferenceTelomereToTelomereV2}}
+		// GRCh37 and GRCh38 share GenBank accessions (CM000663-CM000686).
+		accessions[fmt.Sprintf("CM%06d", 662+num)] = accession{chromosome, []Reference{ReferenceGRCh37, ReferenceGRCh38}}
+		// T2T-CHM13v2.0 autosomes are numbered in reverse (CP068277-CP068256).
+		if num <= 22 {
+			accessions[fmt.Sprintf("CP%06d", 68278-num)] = accession{chromosome, []Reference{ReferenceTelomereToTelomereV2}}
+		}
+	}
+
+	return accessions
+}()
@@ -0,0 +1,89 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package types
+
+// Interval is a confidence interval for an allele frequency.
+type Interval struct {
+	Lower float64 // Lower bound of the interval.
+	Upper float64 // Upper bound of the interval.
+}
+
+// AncestryFrequency is the frequency of an allele in an ancestry group, along
+// with its confidence intervals.
+type AncestryFrequency struct {
+	Ancestry     AncestryGroup // Ancestry group.
+	AlleleCount  int64         // Number of alternate alleles observed (AC).
+	AlleleNumber int64         // Total number of alleles genotyped (AN).
+	Frequency    float64       // Frequency of the allele in the ancestry group (AC/AN).
+	Wilson       Interval      // Wilson score interval of the frequency.
+	Poisson      Interval      // Exact Poisson interval of the frequency.
+	FAF95        float64       // Filtering allele frequency (95% confidence).
+}
+
+// AlleleFrequencyStats are the per ancestry group frequency statistics of an
+// allele of a variant.
+type AlleleFrequencyStats struct {
+	ID        int64               // Unique ID of the variant the allele is associated with (RSID).
+	Reference string              // Reference base(s) at the variant's position.
+	Alternate string              // Alternate base(s) at the variant's position, representing the allele.
+	Ancestry  []AncestryFrequency // Frequency statistics for each ancestry group with known counts.
+	// FAF95 is the maximum filtering allele frequency across all ancestry
+	// groups (excluding bottlenecked groups), as used by gnomAD.
+	FAF95 float64
+	// FAF95Ancestry is the ancestry group with the maximum filtering allele frequency.
+	FAF95Ancestry AncestryGroup
+}
+
+// AlleleFrequencies is the frequency of an allele of a variant in each
+// ancestry group.
+type AlleleFrequencies struct {
+	ID          int64                     // Unique ID of the variant the allele is associated with (RSID).
+	Reference   string                    // Reference base(s) at the variant's position.
+	Alternate   string                    // Alternate base(s) at the variant's position, representing the allele.
+	Frequencies map[AncestryGroup]float64 // Frequency of the allele in each ancestry group.
+}
+
+// MaxFrequency returns the ancestry group with the highest frequency of the
+// allele (excluding AncestryGroupAll), and the frequency. If there are no
+// per ancestry frequencies, false is returned.
+func (f *AlleleFrequencies) MaxFrequency() (AncestryGroup, float64, bool) {
+	return f.max(func(AncestryGroup) bool { return true })
+}
+
+// PopMax returns the ancestry group with the highest frequency of the allele,
+// excluding bottlenecked populations (as in gnomAD), and the frequency. If
+// there are no eligible frequencies, false is returned.
+func (f *AlleleFrequencies) PopMax() (AncestryGroup, float64, bool) {
+	return f.max(func(ancestry AncestryGroup) bool { return !ancestry.Bottlenecked() })
+}
+
+func (f *AlleleFrequencies) max(include func(AncestryGroup) bool) (AncestryGroup, float64, bool) {
+	var maxAncestry AncestryGroup
+	maxFrequency := -1.0
+	for ancestry, frequency := range f.Frequencies {
+		if ancestry == AncestryGroupAll || !include(ancestry) {
+			continue
+		}
+
+		// Break ties deterministically.
+		if frequency > maxFrequency || (frequency == maxFrequency && ancestry < maxAncestry) {
+			maxAncestry, maxFrequency = ancestry, frequency
+		}
+	}
+
+	if maxFrequency < 0 {
+		return "", 0, false
+	}
+
+	return maxAncestry, maxFrequency, true
+}
@@ -22,6 +22,7 @@ type Chain struct {
 	RefStrand   string     `db:"ref_strand"`   // Strand in the reference genome ('+' or '-').
 	RefStart    int64      `db:"ref_start"`    // Start position in the reference genome.
 	RefEnd      int64      `db:"ref_end"`      // End position in the reference genome.
+	QueryRef    Reference  `db:"query_ref"`    // Query genome assembly name.
 	QueryName   Chromosome `db:"query_name"`   // Query chromosome name.
 	QuerySize   int64      `db:"query_size"`   // Size of the query chromosome in bases.
 	QueryStrand string     `db:"query_strand"` // Strand in the query genome ('+' or '-').
@@ -37,3 +38,71 @@ type Alignment struct {
 	QueryOffset int64 `db:"query_offset"` // Offset of the aligned block in the query chromosome from the start of the chain.
 	Size        int64 `db:"size"`         // Size of the aligned block in bases.
 }
+
+// LiftedPosition is a position that has been lifted over to another reference genome assembly.
+type LiftedPosition struct {
+	Reference  Reference  // Reference genome assembly the position was lifted to.
+	Chromosome Chromosome // Chromosome in the target assembly.
+	Position   int64      // Position (1-based) on the chromosome in the target assembly.
+	Strand     string     // Strand in the target assembly ('+' or '-').
+}
+
+// ReverseComplemented returns true if the position maps to the reverse strand
+// of the target assembly, and thus any alleles need to be reverse complemented.
+func (p *LiftedPosition) ReverseComplemented() bool {
+	return p.Strand == "-"
+}
+
+// Region is a 0-based, half-open interval [Start, End) on a chromosome (as in BED files).
+type Region struct {
+	Chromosome Chromosome // Chromosome the region is located on.
+	Start      int64      // Start position of the region (0-based, inclusive).
+	End        int64      // End position of the region (0-based, exclusive).
+}
+
+// Length returns the length of the region in bases.
+func (r Region) Length() int64 {
+	return r.End - r.Start
+}
+
+// LiftedRegion is a region (or a piece of one) that has been lifted over to
+// another reference genome assembly.
+type LiftedRegion struct {
+	Reference   Reference  // Reference genome assembly the region was lifted to.
+	Chromosome  Chromosome // Chromosome in the target assembly.
+	Start       int64      // Start position in the target assembly (0-based, inclusive).
+	End         int64      // End position in the target assembly (0-based, exclusive).
+	Strand      string     // Strand in the target assembly ('+' or '-').
+	Source      Region     // The portion of the source region that was lifted.
+	MappedBases int64      // Number of bases of the source region aligned to the target.
+	Coverage    float64    // Fraction of the source region aligned to the target.
+}
+
+// Locus is a (1-based) position on a chromosome.
+type Locus struct {
+	Chromosome Chromosome // Chromosome the locus is located on.
+	Position   int64      // Position (1-based) on the chromosome.
+}
+
+// LiftoverStatus is the outcome of lifting over a variant.
+type LiftoverStatus string
+
+const (
+	// LiftoverStatusClean means the variant was lifted over with its alleles unchanged
+	// (other than being reverse complemented).
+	LiftoverStatusClean LiftoverStatus = "CLEAN"
+	// LiftoverStatusSwapped means the reference base changed between assemblies,
+	// so the reference and alternate alleles were swapped.
+	LiftoverStatusSwapped LiftoverStatus = "SWAPPED"
+	// LiftoverStatusRejected means the variant could not be lifted over.
+	LiftoverStatusRejected LiftoverStatus = "REJECTED"
+)
+
+// LiftedVariant is a variant that has been lifted over to another reference genome assembly.
+type LiftedVariant struct {
+	Variant             Variant        // The variant, positioned in the target assembly.
+	Alleles             []Allele       // The alleles of the variant, adjusted for the target assembly.
+	Status              LiftoverStatus // The outcome of lifting over the variant.
+	ReverseComplemented bool           // Whether the alleles were reverse complemented.
+	Reason              string         // Why the variant was rejected (if it was).
+}
@@ -0,0 +1,144 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package types
+
+import "fmt"
+
+// PseudoautosomalRegion is the location of a pseudoautosomal region on the sex
+// chromosomes of a reference genome assembly. Positions within the region
+// (eg. of variants stored on ChrPAR) are 1-based offsets from the start of the
+// region on chromosome X.
+type PseudoautosomalRegion struct {
+	Chromosome Chromosome // The pseudoautosomal region (ChrPAR or ChrPAR2).
+	X          Region     // Location of the region on chromosome X.
+	Y          Region     // Location of the region on chromosome Y.
+}
+
+// Length returns the length of the region in bases (on chromosome X).
+func (r PseudoautosomalRegion) Length() int64 {
+	return r.X.Length()
+}
+
+// PseudoautosomalRegions returns the pseudoautosomal regions of a reference
+// genome assembly.
+func PseudoautosomalRegions(reference Reference) ([]PseudoautosomalRegion, error) {
+	regions, ok := pseudoautosomalRegions[reference]
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference genome assembly: %s", reference)
+	}
+
+	return regions, nil
+}
+
+// ToPseudoautosomal maps a 1-based position on chromosome X or Y into the
+// coordinates of the pseudoautosomal region containing it. False is returned
+// if the position is not within a pseudoautosomal region.
+func ToPseudoautosomal(reference Reference, chromosome Chromosome, position int64) (*Locus, bool, error) {
+	if chromosome != ChrX && chromosome != ChrY {
+		return nil, false, nil
+	}
+
+	regions, err := PseudoautosomalRegions(reference)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, region := range regions {
+		bounds := region.X
+		if chromosome == ChrY {
+			bounds = region.Y
+		}
+
+		if position > bounds.Start && position <= bounds.End {
+			if chromosome == ChrY {
+				if err := region.checkColinear(reference); err != nil {
+					return nil, false, err
+				}
+			}
+
+			return &Locus{
+				Chromosome: region.Chromosome,
+				Position:   position - bounds.Start,
+			}, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// FromPseudoautosomal maps a 1-based position within a pseudoautosomal region
+// (ChrPAR or ChrPAR2) onto chromosome X or Y.
+func FromPseudoautosomal(reference Reference, par Chromosome, position int64, chromosome Chromosome) (int64, error) {
+	if chromosome != ChrX && chromosome != ChrY {
+		return 0, fmt.Errorf("pseudoautosomal regions are only on chromosomes X and Y, not %s", chromosome)
+	}
+
+	regions, err := PseudoautosomalRegions(reference)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, region := range regions {
+		if region.Chromosome != par {
+			continue
+		}
+
+		if position < 1 || position > region.Length() {
+			return 0, fmt.Errorf("position %d is outside of pseudoautosomal region %s", position, par)
+		}
+
+		bounds := region.X
+		if chromosome == ChrY {
+			if err := region.checkColinear(reference); err != nil {
+				return 0, err
+			}
+
+			bounds = region.Y
+		}
+
+		return bounds.Start + position, nil
+	}
+
+	return 0, fmt.Errorf("not a pseudoautosomal region: %s", par)
+}
+
+// checkColinear returns an error if the region has a different length on
+// chromosome Y, in which case positions can't be mapped between X and Y
+// without an alignment (eg. on T2T-CHM13v2.0, where chromosome Y is from HG002).
+func (r PseudoautosomalRegion) checkColinear(reference Reference) error {
+	if r.Y.Length() != r.X.Length() {
+		return fmt.Errorf("pseudoautosomal region %s on chromosome Y is not colinear with chromosome X on %s", r.Chromosome, reference)
+	}
+
+	return nil
+}
+
+// The pseudoautosomal regions of the supported reference genome assemblies.
+var pseudoautosomalRegions = map[Reference][]PseudoautosomalRegion{
+	ReferenceNCBI36: {
+		{ChrPAR, Region{ChrX, 0, 2709520}, Region{ChrY, 0, 2709520}},
+		{ChrPAR2, Region{ChrX, 154584237, 154913754}, Region{ChrY, 57443437, 57772954}},
+	},
+	ReferenceGRCh37: {
+		{ChrPAR, Region{ChrX, 60000, 2699520}, Region{ChrY, 10000, 2649520}},
+		{ChrPAR2, Region{ChrX, 154931043, 155260560}, Region{ChrY, 59034049, 59363566}},
+	},
+	ReferenceGRCh38: {
+		{ChrPAR, Region{ChrX, 10000, 2781479}, Region{ChrY, 10000, 2781479}},
+		{ChrPAR2, Region{ChrX, 155701382, 156030895}, Region{ChrY, 56887902, 57217415}},
+	},
+	ReferenceTelomereToTelomereV2: {
+		{ChrPAR, Region{ChrX, 0, 2394410}, Region{ChrY, 0, 2458320}},
+		{ChrPAR2, Region{ChrX, 153925834, 154259566}, Region{ChrY, 62122809, 62460029}},
+	},
+}
@@ -12,6 +12,8 @@
 
 package types
 
+import "fmt"
+
 // Reference is a reference genome assembly.
 type Reference string
 
@@ -21,3 +23,20 @@ const (
 	ReferenceGRCh38               Reference = "GRCh38"
 	ReferenceTelomereToTelomereV2 Reference = "T2T-CHM13v2.0"
 )
+
+// Scan implements the sql.Scanner interface, an unknown (NULL) assembly is
+// scanned as an empty string.
+func (r *Reference) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*r = ""
+	case string:
+		*r = Reference(v)
+	case []byte:
+		*r = Reference(v)
+	default:
+		return fmt.Errorf("could not scan %T into reference", src)
+	}
+
+	return nil
+}
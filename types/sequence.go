@@ -0,0 +1,58 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package types
+
+// ReverseComplement returns the reverse complement of a nucleotide sequence.
+// IUPAC ambiguity codes are supported, and case is preserved.
+func ReverseComplement(sequence string) string {
+	complemented := make([]byte, len(sequence))
+	for i := 0; i < len(sequence); i++ {
+		complemented[len(sequence)-1-i] = complement(sequence[i])
+	}
+
+	return string(complemented)
+}
+
+func complement(base byte) byte {
+	switch base {
+	case 'A':
+		return 'T'
+	case 'C':
+		return 'G'
+	case 'G':
+		return 'C'
+	case 'T':
+		return 'A'
+	case 'R':
+		return 'Y'
+	case 'Y':
+		return 'R'
+	case 'K':
+		return 'M'
+	case 'M':
+		return 'K'
+	case 'B':
+		return 'V'
+	case 'V':
+		return 'B'
+	case 'D':
+		return 'H'
+	case 'H':
+		return 'D'
+	case 'a', 'c', 'g', 't', 'r', 'y', 'k', 'm', 'b', 'v', 'd', 'h':
+		return complement(base-'a'+'A') - 'A' + 'a'
+	default:
+		// N, S, W, gaps etc. are their own complement.
+		return base
+	}
+}
@@ -12,6 +12,11 @@
 
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 // VariantClass is the class of a genomic variant.
 type VariantClass string
 
@@ -34,6 +39,55 @@ type Variant struct {
 	Chromosome Chromosome   `db:"chromosome"` // Chromosome on which the variant is located.
 	Position   int64        `db:"position"`   // Position of the variant on the chromosome.
 	Class      VariantClass `db:"class"`      // Class of the variant, e.g., SNV, INDEL, INS, DEL, MNV.
+	// Positions of the variant on each reference genome assembly it is known
+	// on. The chromosome and position above are on GRCh38.
+	Positions map[Reference]Locus `db:"-"`
+}
+
+// VariantPosition is the position of a variant on a reference genome assembly.
+type VariantPosition struct {
+	ID         int64      `db:"id"`         // Unique ID of the variant (RSID).
+	Reference  Reference  `db:"ref"`        // Reference genome assembly.
+	Chromosome Chromosome `db:"chromosome"` // Chromosome on which the variant is located.
+	Position   int64      `db:"position"`   // Position of the variant on the chromosome.
+}
+
+// SyntheticVariantID returns a coordinate derived ID for a variant that has no
+// RSID (eg. a novel variant). Synthetic IDs are negative, so that they never
+// collide with RSIDs.
+func SyntheticVariantID(chromosome Chromosome, position int64) (int64, error) {
+	index := chromosome.int()
+	if index <= 0 {
+		return 0, fmt.Errorf("unsupported chromosome: %s", chromosome)
+	}
+
+	if position <= 0 || position >= 1<<32 {
+		return 0, fmt.Errorf("position out of range: %d", position)
+	}
+
+	return -(int64(index)<<32 | position), nil
+}
+
+// HasRSID returns true if the variant has a real (rather than synthetic) RSID.
+func (v *Variant) HasRSID() bool {
+	return v.ID > 0
+}
+
+// ClassifyVariant returns the class of a variant given its reference and
+// alternate alleles (as in dbSNP).
+func ClassifyVariant(reference, alternate string) VariantClass {
+	switch {
+	case len(reference) == len(alternate) && len(reference) == 1:
+		return VariantClassSNV
+	case len(reference) == len(alternate):
+		return VariantClassMNV
+	case len(reference) < len(alternate) && strings.HasPrefix(alternate, reference):
+		return VariantClassINS
+	case len(reference) > len(alternate) && strings.HasPrefix(reference, alternate):
+		return VariantClassDEL
+	default:
+		return VariantClassINDEL
+	}
 }
 
 // AncestryGroup identifies an ancestry group (as found in gnoMAD v3).
@@ -63,6 +117,19 @@ const (
 	AncestryGroupOther AncestryGroup = "OTH"
 )
 
+// Bottlenecked returns true if the ancestry group is a bottlenecked (founder)
+// population, whose allele frequencies can be inflated. These groups are
+// excluded from the "popmax" frequency (as in gnomAD).
+func (a AncestryGroup) Bottlenecked() bool {
+	switch a {
+	case AncestryGroupAmish, AncestryGroupAshkenazi, AncestryGroupFinnish,
+		AncestryGroupMiddleEastern, AncestryGroupOther:
+		return true
+	}
+
+	return false
+}
+
 // Allele is an allele of a genomic variant.
 type Allele struct {
 	ID        int64         `db:"id"`        // Unique ID of the variant the allele is associated with (RSID).
@@ -70,4 +137,28 @@ type Allele struct {
 	Alternate string        `db:"alt"`       // Alternate base(s) at the variant's position, representing the allele.
 	Ancestry  AncestryGroup `db:"ancestry"`  // Ancestry group the allele is associated with.
 	Frequency float64       `db:"frequency"` // Frequency of the allele in the ancestry group.
+	// The following counts are optional, and are nil if unknown.
+	AlleleCount     *int64 `db:"allele_count"`     // Number of alternate alleles observed (AC).
+	AlleleNumber    *int64 `db:"allele_number"`    // Total number of alleles genotyped (AN).
+	HomozygoteCount *int64 `db:"homozygote_count"` // Number of individuals homozygous for the alternate allele.
+	HemizygoteCount *int64 `db:"hemizygote_count"` // Number of individuals hemizygous for the alternate allele.
+}
+
+// ReverseComplement returns a copy of the allele with the reference and
+// alternate bases reverse complemented, eg. after lifting over to the
+// reverse strand.
+func (a Allele) ReverseComplement() Allele {
+	a.Reference = ReverseComplement(a.Reference)
+	a.Alternate = ReverseComplement(a.Alternate)
+	return a
+}
+
+// ReferenceMismatch is an allele whose reference base(s) do not match the
+// reference genome assembly at the position of its variant.
+type ReferenceMismatch struct {
+	ID         int64      // Unique ID of the variant the allele is associated with (RSID).
+	Chromosome Chromosome // Chromosome on which the variant is located.
+	Position   int64      // Position of the variant on the chromosome.
+	Reference  string     // Reference base(s) of the allele.
+	Expected   string     // Base(s) of the reference genome assembly at the variant's position.
 }
@@ -0,0 +1,216 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/zymatik-com/genobase/types"
+)
+
+// The number of alleles to read at a time when auditing.
+const auditBatchSize = 10000
+
+// ErrReferenceMismatch is returned when the reference base(s) of an allele do
+// not match the reference genome assembly.
+var ErrReferenceMismatch = errors.New("reference allele does not match reference genome")
+
+// ReferenceMismatchError is returned when storing alleles whose reference
+// base(s) do not match the reference genome assembly.
+type ReferenceMismatchError struct {
+	Mismatches []types.ReferenceMismatch // The mismatched alleles.
+}
+
+func (e *ReferenceMismatchError) Error() string {
+	m := e.Mismatches[0]
+	msg := fmt.Sprintf("%v: variant %d at %s:%d has %s, expected %s", ErrReferenceMismatch,
+		m.ID, m.Chromosome, m.Position, m.Reference, m.Expected)
+	if len(e.Mismatches) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Mismatches)-1)
+	}
+
+	return msg
+}
+
+func (e *ReferenceMismatchError) Unwrap() error {
+	return ErrReferenceMismatch
+}
+
+type storeOptions struct {
+	validate bool
+}
+
+// StoreOption configures storing alleles.
+type StoreOption func(*storeOptions)
+
+// ValidateReference checks the reference base(s) of alleles against the
+// reference genome before they are stored. If any don't match, none of the
+// alleles are stored and a *ReferenceMismatchError is returned. Alleles on
+// chromosomes without a reference sequence are stored unchecked.
+func ValidateReference(opts *storeOptions) {
+	opts.validate = true
+}
+
+// ValidateAlleles checks the reference base(s) of alleles against the GRCh38
+// reference genome at the position of their variant, returning any mismatches.
+// The sequence source is used if set, otherwise the imported sequence. Alleles
+// whose variant is not stored, or is on a chromosome without a reference
+// sequence, are not checked.
+func (db *DB) ValidateAlleles(ctx context.Context, alleles []types.Allele) ([]types.ReferenceMismatch, error) {
+	ids := make([]int64, 0, len(alleles))
+	for _, allele := range alleles {
+		ids = append(ids, allele.ID)
+	}
+
+	variants, err := db.GetVariantsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []types.ReferenceMismatch
+	for _, allele := range alleles {
+		variant, ok := variants[allele.ID]
+		if !ok {
+			continue
+		}
+
+		mismatch, _, err := db.validateAllele(ctx, variant.Chromosome, variant.Position, allele.ID, allele.Reference)
+		if err != nil {
+			return nil, err
+		}
+
+		if mismatch != nil {
+			mismatches = append(mismatches, *mismatch)
+		}
+	}
+
+	return mismatches, nil
+}
+
+// AuditAlleles checks the reference base(s) of every stored allele against the
+// GRCh38 reference genome at the position of its variant, calling fn for each
+// mismatch. The sequence source is used if set, otherwise the imported sequence.
+// Alleles on chromosomes without a reference sequence can't be checked, and are
+// skipped (they are counted in the logs as unverified). If fn returns an error
+// (or the context is cancelled) the audit stops and the error is returned.
+func (db *DB) AuditAlleles(ctx context.Context, fn func(mismatch *types.ReferenceMismatch) error) error {
+	var chromosomes []types.Chromosome
+	if err := db.db.SelectContext(ctx, &chromosomes, "SELECT DISTINCT chromosome FROM variant ORDER BY chromosome"); err != nil {
+		return fmt.Errorf("could not query chromosomes: %w", err)
+	}
+
+	for _, chromosome := range chromosomes {
+		var checked, mismatched, unverified int
+
+		// Page through the alleles, so that we aren't holding open a result
+		// set while querying the sequence.
+		last := auditedAllele{ID: math.MinInt64}
+
+		for {
+			var page []auditedAllele
+			if err := db.db.SelectContext(ctx, &page, `SELECT DISTINCT variant.position, allele.id, allele.ref FROM allele
+				JOIN variant ON variant.id = allele.id
+				WHERE variant.chromosome = ? AND (variant.position, allele.id, allele.ref) > (?, ?, ?)
+				ORDER BY variant.position, allele.id, allele.ref LIMIT ?`,
+				chromosome, last.Position, last.ID, last.Reference, auditBatchSize); err != nil {
+				return fmt.Errorf("could not query alleles: %w", err)
+			}
+
+			for _, allele := range page {
+				mismatch, verified, err := db.validateAllele(ctx, chromosome, allele.Position, allele.ID, allele.Reference)
+				if err != nil {
+					return err
+				}
+
+				if !verified {
+					unverified++
+					continue
+				}
+
+				checked++
+
+				if mismatch != nil {
+					mismatched++
+
+					if err := fn(mismatch); err != nil {
+						return err
+					}
+				}
+			}
+
+			if len(page) < auditBatchSize {
+				break
+			}
+
+			last = page[len(page)-1]
+		}
+
+		db.logger.Info("Audited alleles", "chromosome", chromosome, "checked", checked, "mismatched", mismatched, "unverified", unverified)
+	}
+
+	return nil
+}
+
+type auditedAllele struct {
+	Position  int64  `db:"position"`
+	ID        int64  `db:"id"`
+	Reference string `db:"ref"`
+}
+
+// validateAllele checks the reference base(s) of an allele, returning a
+// mismatch if they don't match the reference genome. False is returned if the
+// allele could not be checked, as there is no sequence for its chromosome.
+func (db *DB) validateAllele(ctx context.Context, chromosome types.Chromosome, position, id int64, reference string) (*types.ReferenceMismatch, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	seq := db.referenceSequence()
+
+	// The sequence of the pseudoautosomal regions is that of chromosome X.
+	sequenceChromosome, sequencePosition := chromosome, position
+	if chromosome == types.ChrPAR || chromosome == types.ChrPAR2 {
+		var err error
+		sequenceChromosome = types.ChrX
+		sequencePosition, err = types.FromPseudoautosomal(types.ReferenceGRCh38, chromosome, position, types.ChrX)
+		if err != nil {
+			return nil, false, fmt.Errorf("could not map variant %d: %w", id, err)
+		}
+	}
+
+	expected, err := seq.GetSequence(ctx, types.ReferenceGRCh38, sequenceChromosome, sequencePosition-1, sequencePosition-1+int64(len(reference)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("could not get reference sequence: %w", err)
+	}
+
+	if strings.EqualFold(expected, reference) {
+		return nil, true, nil
+	}
+
+	return &types.ReferenceMismatch{
+		ID:         id,
+		Chromosome: chromosome,
+		Position:   position,
+		Reference:  reference,
+		Expected:   expected,
+	}, true, nil
+}
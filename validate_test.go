@@ -0,0 +1,158 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package genobase_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/neilotoole/slogt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase"
+	"github.com/zymatik-com/genobase/types"
+)
+
+func TestValidateAlleles(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	f, err := os.Open("fasta/testdata/example.fa")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+
+	require.NoError(t, db.ImportSequence(ctx, types.ReferenceGRCh38, f))
+
+	require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+		{ID: 1, Chromosome: types.Chr1, Position: 5, Class: types.VariantClassSNV},
+		{ID: 2, Chromosome: types.Chr1, Position: 6, Class: types.VariantClassSNV},
+		{ID: 3, Chromosome: types.Chr2, Position: 4, Class: types.VariantClassDEL},
+	}))
+
+	alleles := []types.Allele{
+		{ID: 1, Reference: "A", Alternate: "G", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+		{ID: 1, Reference: "A", Alternate: "G", Ancestry: types.AncestryGroupAfrican, Frequency: 0.2},
+		// Strand error.
+		{ID: 2, Reference: "G", Alternate: "A", Ancestry: types.AncestryGroupAll, Frequency: 0.3},
+		{ID: 3, Reference: "TG", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.4},
+	}
+
+	t.Run("Validate", func(t *testing.T) {
+		mismatches, err := db.ValidateAlleles(ctx, alleles)
+		require.NoError(t, err)
+
+		assert.Equal(t, []types.ReferenceMismatch{
+			{ID: 2, Chromosome: types.Chr1, Position: 6, Reference: "G", Expected: "C"},
+		}, mismatches)
+	})
+
+	t.Run("Store", func(t *testing.T) {
+		err := db.StoreAlleles(ctx, alleles, genobase.ValidateReference)
+		require.ErrorIs(t, err, genobase.ErrReferenceMismatch)
+
+		var mismatchErr *genobase.ReferenceMismatchError
+		require.True(t, errors.As(err, &mismatchErr))
+		assert.Len(t, mismatchErr.Mismatches, 1)
+
+		// Nothing is stored.
+		known, err := db.KnownAlleles(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, known)
+
+		require.NoError(t, db.StoreAlleles(ctx, alleles[:2], genobase.ValidateReference))
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		require.NoError(t, db.StoreAlleles(ctx, alleles))
+
+		var mismatches []types.ReferenceMismatch
+		err := db.AuditAlleles(ctx, func(mismatch *types.ReferenceMismatch) error {
+			mismatches = append(mismatches, *mismatch)
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []types.ReferenceMismatch{
+			{ID: 2, Chromosome: types.Chr1, Position: 6, Reference: "G", Expected: "C"},
+		}, mismatches)
+	})
+
+	t.Run("Unverifiable", func(t *testing.T) {
+		// There is no sequence for the mitochondrial DNA.
+		require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+			{ID: 4, Chromosome: types.ChrMT, Position: 73, Class: types.VariantClassSNV},
+		}))
+
+		unverifiable := []types.Allele{
+			{ID: 4, Reference: "A", Alternate: "G", Ancestry: types.AncestryGroupAll, Frequency: 0.5},
+		}
+
+		mismatches, err := db.ValidateAlleles(ctx, unverifiable)
+		require.NoError(t, err)
+		assert.Empty(t, mismatches)
+
+		require.NoError(t, db.StoreAlleles(ctx, unverifiable, genobase.ValidateReference))
+
+		var audited []types.ReferenceMismatch
+		err = db.AuditAlleles(ctx, func(mismatch *types.ReferenceMismatch) error {
+			audited = append(audited, *mismatch)
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Len(t, audited, 1)
+	})
+}
+
+func TestValidateAllelesPseudoautosomal(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := genobase.Open(ctx, slogt.New(t), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	// The first base of PAR1 is chrX:10001.
+	db.SetSequenceSource(fakeSequence{
+		{Chromosome: types.ChrX, Position: 10001}: 'C',
+	})
+
+	require.NoError(t, db.StoreVariants(ctx, []types.Variant{
+		{ID: 1, Chromosome: types.ChrPAR, Position: 1, Class: types.VariantClassSNV},
+	}))
+
+	mismatches, err := db.ValidateAlleles(ctx, []types.Allele{
+		{ID: 1, Reference: "C", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	mismatches, err = db.ValidateAlleles(ctx, []types.Allele{
+		{ID: 1, Reference: "G", Alternate: "T", Ancestry: types.AncestryGroupAll, Frequency: 0.1},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []types.ReferenceMismatch{
+		{ID: 1, Chromosome: types.ChrPAR, Position: 1, Reference: "G", Expected: "C"},
+	}, mismatches)
+}
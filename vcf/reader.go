@@ -0,0 +1,148 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+// Package vcf reads the site information from Variant Call Format (VCF) files.
+// See: https://samtools.github.io/hts-specs/VCFv4.3.pdf
+package vcf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/zymatik-com/genobase/internal/decompress"
+)
+
+// The maximum length of a line, gnomAD records can have very large INFO fields.
+const maxLineLength = 64 * 1024 * 1024
+
+// Record is a single site (data line) in a VCF file.
+// Genotype (sample) columns are ignored.
+type Record struct {
+	// Chromosome is the chromosome name, exactly as it appears in the file.
+	Chromosome string
+	// Position is the 1-based position of the first reference base.
+	Position int64
+	// IDs are the identifiers of the site (eg. "rs334").
+	IDs []string
+	// Reference is the reference base(s).
+	Reference string
+	// Alternate are the alternate alleles.
+	Alternate []string
+	// Filter are the filters that the site has failed, or "PASS" if it
+	// passed all filters. Empty if no filters have been applied.
+	Filter []string
+
+	rawInfo string
+	info    map[string]string
+}
+
+// Info returns the value of an INFO field. Flags have an empty value.
+func (r *Record) Info(key string) (string, bool) {
+	if r.info == nil {
+		r.info = make(map[string]string)
+		for _, field := range strings.Split(r.rawInfo, ";") {
+			if field == "" || field == "." {
+				continue
+			}
+
+			k, v, _ := strings.Cut(field, "=")
+			r.info[k] = v
+		}
+	}
+
+	value, ok := r.info[key]
+	return value, ok
+}
+
+// Reader is a streaming reader for VCF files.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReader returns a new VCF reader. Gzip (and bgzip) compressed input is
+// detected and decompressed automatically.
+func NewReader(r io.Reader) (*Reader, error) {
+	dr, err := decompress.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(dr)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+
+	return &Reader{
+		scanner: scanner,
+	}, nil
+}
+
+// Next returns the next record in the file, header lines are skipped.
+// When there are no more records, io.EOF is returned.
+func (r *Reader) Next() (*Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		line := r.scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		record, err := parseRecord(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+
+		return record, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read vcf file: %w", err)
+	}
+
+	return nil, io.EOF
+}
+
+// parseRecord parses a data line, eg.
+// "CHROM POS ID REF ALT QUAL FILTER INFO [FORMAT SAMPLE...]".
+func parseRecord(line string) (*Record, error) {
+	fields := strings.SplitN(line, "\t", 9)
+	if len(fields) < 8 {
+		return nil, fmt.Errorf("malformed record")
+	}
+
+	position, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse position: %w", err)
+	}
+
+	return &Record{
+		Chromosome: fields[0],
+		Position:   position,
+		IDs:        splitList(fields[2], ";"),
+		Reference:  fields[3],
+		Alternate:  splitList(fields[4], ","),
+		Filter:     splitList(fields[6], ";"),
+		rawInfo:    fields[7],
+	}, nil
+}
+
+// splitList splits a list valued column, "." denotes a missing value.
+func splitList(value, sep string) []string {
+	if value == "" || value == "." {
+		return nil
+	}
+
+	return strings.Split(value, sep)
+}
@@ -0,0 +1,82 @@
+/* SPDX-License-Identifier: MPL-2.0
+ *
+ * Zymatik Genobase - A human genomics reference DB.
+ * Copyright (C) 2024 Damian Peckett <damian@pecke.tt>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Mozilla Public License v2.0.
+ *
+ * You should have received a copy of the Mozilla Public License v2.0
+ * along with this program. If not, see <https://mozilla.org/MPL/2.0/>.
+ */
+
+package vcf_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zymatik-com/genobase/vcf"
+)
+
+func TestReader(t *testing.T) {
+	data, err := os.ReadFile("testdata/example.vcf")
+	require.NoError(t, err)
+
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err = gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	for name, input := range map[string][]byte{
+		"Plain":      data,
+		"Compressed": compressed.Bytes(),
+	} {
+		input := input
+
+		t.Run(name, func(t *testing.T) {
+			r, err := vcf.NewReader(bytes.NewReader(input))
+			require.NoError(t, err)
+
+			record, err := r.Next()
+			require.NoError(t, err)
+
+			assert.Equal(t, "NC_000011.10", record.Chromosome)
+			assert.Equal(t, int64(5227002), record.Position)
+			assert.Equal(t, []string{"rs334"}, record.IDs)
+			assert.Equal(t, "T", record.Reference)
+			assert.Equal(t, []string{"A", "C", "G"}, record.Alternate)
+			assert.Empty(t, record.Filter)
+
+			vc, ok := record.Info("VC")
+			assert.True(t, ok)
+			assert.Equal(t, "SNV", vc)
+
+			_, ok = record.Info("COMMON")
+			assert.True(t, ok)
+
+			_, ok = record.Info("FREQ")
+			assert.False(t, ok)
+
+			record, err = r.Next()
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"rs4680"}, record.IDs)
+			assert.Equal(t, []string{"PASS"}, record.Filter)
+
+			for i := 0; i < 2; i++ {
+				_, err = r.Next()
+				require.NoError(t, err)
+			}
+
+			_, err = r.Next()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}